@@ -0,0 +1,461 @@
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/evgenivanovi/gpl/std"
+	"github.com/evgenivanovi/gpl/std/conv"
+	ref "github.com/evgenivanovi/gpl/std/reflect"
+	"github.com/evgenivanovi/gpl/stdx"
+	"github.com/evgenivanovi/gpl/stdx/mex"
+	"github.com/gookit/goutil/strutil"
+	me "github.com/hashicorp/go-multierror"
+)
+
+const (
+	TagKey     = "cfg"
+	TagEnv     = "env"
+	TagFlag    = "flag"
+	TagDefault = "default"
+	TagSecret  = "secret"
+)
+
+var ErrBindTarget = errors.New("bind target must be a non-nil pointer to a struct")
+var ErrFieldUnsupported = errors.New("field type is not supported")
+
+type BindOp func(*binder)
+
+// WithBindRegistry
+// Reads the files into the provided registry instead of the default one
+// and registers a property for every bound field in it.
+func WithBindRegistry(registry *Registry) BindOp {
+	return func(b *binder) {
+		b.registry = registry
+	}
+}
+
+// WithBindArgOps
+// Configures the flags of the fields, e.g. binds them to the flag set of the command with WithPFlagSet.
+func WithBindArgOps(ops ...ArgSourceOp) BindOp {
+	return func(b *binder) {
+		b.args = append(b.args, ops...)
+	}
+}
+
+// WithBindJSONFile
+// Looks up every field by its `cfg` key in the provided JSON file.
+func WithBindJSONFile(path string) BindOp {
+	return func(b *binder) {
+		b.files = append(b.files, func(key string) Source {
+			return b.registry.NewJSONFileSource(key, path)
+		})
+	}
+}
+
+// WithBindYAMLFile
+// Looks up every field by its `cfg` key in the provided YAML file.
+func WithBindYAMLFile(path string) BindOp {
+	return func(b *binder) {
+		b.files = append(b.files, func(key string) Source {
+			return b.registry.NewYAMLFileSource(key, path)
+		})
+	}
+}
+
+// WithBindFile
+// Looks up every field by its `cfg` key in the provided file of any supported format.
+func WithBindFile(path string) BindOp {
+	return func(b *binder) {
+		b.files = append(b.files, func(key string) Source {
+			return b.registry.NewFileSource(key, path)
+		})
+	}
+}
+
+type binder struct {
+	registry *Registry
+	files    []func(key string) Source
+	args     []ArgSourceOp
+	errs     *me.Error
+}
+
+// Bind
+// Populates the struct pointed to by target from the tags of its fields:
+//
+//	Host string `cfg:"db.host" env:"PGHOST" flag:"db-host" default:"localhost"`
+//
+// Sources are consulted in the order flag, env, files, default and
+// the first non-blank value wins. Without file options the keys are looked up
+// in the files already loaded into the registry, e.g. by LoadProfiles. Nested structs are walked recursively,
+// the `cfg` tag of a struct field is used as a prefix for the keys of its fields.
+// A field without a `default` tag is required.
+//
+// A field of the Secret type or tagged with `secret:"true"` is bound to a secret property
+// and is also looked up in the file referenced by the `*_FILE` variant of its `env` tag.
+//
+// Fields are converted by the parser registered for their type with RegisterParser,
+// fields of other types are converted according to their kind.
+//
+// Every missing or unparsable field is reported in the single returned error.
+func Bind(target any, ops ...BindOp) error {
+
+	value := reflect.ValueOf(target)
+	if !value.IsValid() || value.Kind() != reflect.Pointer || value.IsNil() {
+		return ErrBindTarget
+	}
+
+	value = value.Elem()
+	if value.Kind() != reflect.Struct {
+		return ErrBindTarget
+	}
+
+	b := &binder{
+		registry: defaultRegistry,
+		errs: &me.Error{
+			ErrorFormat: mex.AppendFormat(std.SemicolonSpace),
+		},
+	}
+
+	for _, op := range ops {
+		op(b)
+	}
+
+	b.bindStruct(value, std.Empty, std.Empty)
+	return b.errs.ErrorOrNil()
+
+}
+
+func (b *binder) bindStruct(value reflect.Value, path string, prefix string) {
+
+	typ := value.Type()
+
+	for index := 0; index < typ.NumField(); index++ {
+
+		field := typ.Field(index)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldPath := joinNonEmpty(std.Dot, path, field.Name)
+		fieldValue := value.Field(index)
+
+		if field.Type.Kind() == reflect.Struct && !isParsed(field.Type) {
+			key, _ := field.Tag.Lookup(TagKey)
+			b.bindStruct(fieldValue, fieldPath, joinNonEmpty(std.Dot, prefix, key))
+			continue
+		}
+
+		if !hasBindTags(field.Tag) {
+			continue
+		}
+
+		if err := b.bindField(fieldValue, field, prefix); err != nil {
+			b.errs = me.Append(b.errs, fmt.Errorf("field '%s': %w", fieldPath, err))
+		}
+
+	}
+
+}
+
+func (b *binder) bindField(value reflect.Value, field reflect.StructField, prefix string) error {
+
+	binding, ok := bindingFor(field.Type)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrFieldUnsupported, field.Type)
+	}
+
+	secret := isSecretField(field)
+	sources := b.sources(field.Tag, field.Type, prefix, secret)
+	b.registry.Register(
+		&Property{
+			name:    propertyName(field, prefix),
+			sources: sources,
+			secret:  secret,
+		},
+	)
+
+	for _, source := range sources {
+
+		raw, present := source.Get()
+		if !present || strutil.IsBlank(raw) {
+			continue
+		}
+
+		if err := binding.apply(value, source); err != nil {
+			// Parsers quote the input, the error of a secret is not wrapped not to reveal it.
+			if secret {
+				return fmt.Errorf(
+					"value '%s' from '%s': value cannot be parsed as %s",
+					Redacted, describe(source), field.Type.Kind(),
+				)
+			}
+			return fmt.Errorf("value '%s' from '%s': %w", raw, describe(source), err)
+		}
+
+		return nil
+
+	}
+
+	if _, optional := field.Tag.Lookup(TagDefault); optional {
+		return nil
+	}
+
+	return ErrPropertyNotFound
+
+}
+
+func (b *binder) sources(tag reflect.StructTag, typ reflect.Type, prefix string, secret bool) []Source {
+
+	sources := make([]Source, 0)
+
+	if name := tag.Get(TagFlag); strutil.IsNotBlank(name) {
+		ops := append([]ArgSourceOp{WithFlagKind(flagKindOf(typ))}, b.args...)
+		sources = append(sources, NewArgSource(name, ops...))
+	}
+
+	if name := tag.Get(TagEnv); strutil.IsNotBlank(name) {
+		sources = append(sources, NewEnvSource(name))
+		if secret {
+			sources = append(sources, NewEnvFileSource(name))
+		}
+	}
+
+	if key := tag.Get(TagKey); strutil.IsNotBlank(key) {
+		key = joinNonEmpty(std.Dot, prefix, key)
+		if len(b.files) == 0 {
+			sources = append(sources, b.registry.NewKeySource(key))
+		}
+		for _, file := range b.files {
+			sources = append(sources, file(key))
+		}
+	}
+
+	if value, ok := tag.Lookup(TagDefault); ok {
+		sources = append(sources, NewValueSource(value))
+	}
+
+	return sources
+
+}
+
+// binding
+// Converts the raw value of a source and assigns it to a field,
+// a binding without assign sets the result of mapping to the field as is.
+type binding struct {
+	mapping func(string) (any, error)
+	assign  func(reflect.Value, stdx.Value)
+}
+
+func (b binding) apply(field reflect.Value, source Source) error {
+
+	if b.assign == nil {
+		raw, _ := source.Get()
+		parsed, err := b.mapping(raw)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(parsed))
+		return nil
+	}
+
+	mapped := source.Map(b.mapping)
+	if nilValue, isNil := mapped.(stdx.NilValue); isNil {
+		return nilValue.Error()
+	}
+
+	b.assign(field, mapped)
+	return nil
+
+}
+
+func bindingFor(typ reflect.Type) (binding, bool) {
+
+	if parser, ok := lookupParser(typ); ok {
+		return binding{mapping: parser}, true
+	}
+
+	if ref.IsDurationType(typ) {
+		return binding{
+			mapping: func(raw string) (any, error) {
+				return conv.MapDuration(raw)
+			},
+			assign: func(field reflect.Value, value stdx.Value) {
+				field.SetInt(int64(value.GetDuration()))
+			},
+		}, true
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		return binding{
+			mapping: func(raw string) (any, error) {
+				return raw, nil
+			},
+			assign: func(field reflect.Value, value stdx.Value) {
+				field.SetString(value.GetString())
+			},
+		}, true
+	case reflect.Bool:
+		return binding{
+			mapping: func(raw string) (any, error) {
+				return conv.MapBool(raw)
+			},
+			assign: func(field reflect.Value, value stdx.Value) {
+				field.SetBool(value.GetBool())
+			},
+		}, true
+	case reflect.Int:
+		return binding{
+			mapping: func(raw string) (any, error) {
+				return conv.MapInt(raw)
+			},
+			assign: func(field reflect.Value, value stdx.Value) {
+				field.SetInt(int64(value.GetInt()))
+			},
+		}, true
+	case reflect.Int64:
+		return binding{
+			mapping: func(raw string) (any, error) {
+				return conv.MapInt64(raw)
+			},
+			assign: func(field reflect.Value, value stdx.Value) {
+				field.SetInt(value.GetInt64())
+			},
+		}, true
+	case reflect.Uint:
+		return binding{
+			mapping: func(raw string) (any, error) {
+				return conv.MapUint(raw)
+			},
+			assign: func(field reflect.Value, value stdx.Value) {
+				field.SetUint(uint64(value.GetUint()))
+			},
+		}, true
+	case reflect.Uint16:
+		return binding{
+			mapping: func(raw string) (any, error) {
+				val, err := conv.MapUint16(raw)
+				return uint(val), err
+			},
+			assign: func(field reflect.Value, value stdx.Value) {
+				field.SetUint(uint64(value.GetUint()))
+			},
+		}, true
+	case reflect.Uint64:
+		return binding{
+			mapping: func(raw string) (any, error) {
+				return conv.MapUint64(raw)
+			},
+			assign: func(field reflect.Value, value stdx.Value) {
+				field.SetUint(value.GetUint64())
+			},
+		}, true
+	case reflect.Float64:
+		return binding{
+			mapping: func(raw string) (any, error) {
+				return conv.MapFloat64(raw)
+			},
+			assign: func(field reflect.Value, value stdx.Value) {
+				field.SetFloat(value.GetFloat64())
+			},
+		}, true
+	case reflect.Slice:
+		if typ.Elem().Kind() != reflect.String {
+			break
+		}
+		return binding{
+			mapping: func(raw string) (any, error) {
+				return splitList(raw), nil
+			},
+			assign: func(field reflect.Value, value stdx.Value) {
+				field.Set(reflect.ValueOf(value.GetStrings()).Convert(field.Type()))
+			},
+		}, true
+	}
+
+	return binding{}, false
+
+}
+
+// flagKindOf
+// Defines boolean fields as boolean flags, so that they can be set without a value.
+// Other fields are defined as string flags and converted by their bindings.
+func flagKindOf(typ reflect.Type) FlagKind {
+	if typ.Kind() == reflect.Bool {
+		return FlagBool
+	}
+	return FlagString
+}
+
+func propertyName(field reflect.StructField, prefix string) string {
+
+	if key := field.Tag.Get(TagKey); strutil.IsNotBlank(key) {
+		return joinNonEmpty(std.Dot, prefix, key)
+	}
+
+	if name := field.Tag.Get(TagEnv); strutil.IsNotBlank(name) {
+		return name
+	}
+
+	if name := field.Tag.Get(TagFlag); strutil.IsNotBlank(name) {
+		return name
+	}
+
+	return joinNonEmpty(std.Dot, prefix, field.Name)
+
+}
+
+func isParsed(typ reflect.Type) bool {
+	_, ok := lookupParser(typ)
+	return ok
+}
+
+func isSecretField(field reflect.StructField) bool {
+
+	if field.Type == reflect.TypeOf(Secret(std.Empty)) {
+		return true
+	}
+
+	secret, err := conv.MapBool(field.Tag.Get(TagSecret))
+	return err == nil && secret
+
+}
+
+func hasBindTags(tag reflect.StructTag) bool {
+	for _, name := range []string{TagKey, TagEnv, TagFlag, TagDefault} {
+		if _, ok := tag.Lookup(name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func describe(source Source) string {
+	if stringer, ok := source.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%T", source)
+}
+
+func splitList(raw string) []string {
+	result := make([]string, 0)
+	for _, item := range strings.Split(raw, std.Comma) {
+		if item = strings.TrimSpace(item); item != std.Empty {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	result := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != std.Empty {
+			result = append(result, part)
+		}
+	}
+	return strings.Join(result, sep)
+}
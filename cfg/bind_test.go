@@ -0,0 +1,131 @@
+package cfg
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const BindYAML = Dir + "/bind.yaml"
+
+type bindDB struct {
+	Host     string `cfg:"host" env:"BIND_TEST_DB_HOST" default:"localhost"`
+	Port     uint16 `cfg:"port" env:"BIND_TEST_DB_PORT"`
+	Password string `cfg:"password" env:"BIND_TEST_DB_PASSWORD"`
+}
+
+type bindConfig struct {
+	DB      bindDB        `cfg:"db"`
+	Timeout time.Duration `env:"BIND_TEST_TIMEOUT" default:"5s"`
+	Debug   bool          `env:"BIND_TEST_DEBUG" default:"false"`
+	Tags    []string      `env:"BIND_TEST_TAGS" default:"a, b"`
+	Retries int           `env:"BIND_TEST_RETRIES"`
+}
+
+func TestBind(t *testing.T) {
+
+	// given
+	t.Setenv("BIND_TEST_DB_PASSWORD", "secret")
+	t.Setenv("BIND_TEST_DEBUG", "true")
+	t.Setenv("BIND_TEST_RETRIES", "3")
+
+	actual := bindConfig{}
+
+	// when
+	err := Bind(&actual, WithBindRegistry(NewRegistry()), WithBindYAMLFile(BindYAML))
+
+	// then
+	if err != nil {
+		t.Fatalf("Bind() error = '%v'", err)
+	}
+
+	expected := bindConfig{
+		DB: bindDB{
+			Host:     "db.internal",
+			Port:     6432,
+			Password: "secret",
+		},
+		Timeout: 5 * time.Second,
+		Debug:   true,
+		Tags:    []string{"a", "b"},
+		Retries: 3,
+	}
+
+	if actual.DB != expected.DB || actual.Timeout != expected.Timeout ||
+		actual.Debug != expected.Debug || actual.Retries != expected.Retries ||
+		strings.Join(actual.Tags, ",") != strings.Join(expected.Tags, ",") {
+		t.Errorf("Bind() = '%+v', want '%+v'", actual, expected)
+	}
+
+}
+
+func TestBind_AggregatesErrors(t *testing.T) {
+
+	// given
+	t.Setenv("BIND_TEST_DB_PORT", "not-a-port")
+	t.Setenv("BIND_TEST_RETRIES", "")
+
+	actual := bindConfig{}
+
+	// when
+	err := Bind(&actual, WithBindRegistry(NewRegistry()))
+
+	// then
+	if err == nil {
+		t.Fatal("Bind() expected error, got nil")
+	}
+
+	if !errors.Is(err, ErrPropertyNotFound) {
+		t.Errorf("Bind() error = '%v', want '%v'", err, ErrPropertyNotFound)
+	}
+
+	for _, field := range []string{"DB.Port", "DB.Password", "Retries"} {
+		if !strings.Contains(err.Error(), "'"+field+"'") {
+			t.Errorf("Bind() error = '%v', does not mention field '%s'", err, field)
+		}
+	}
+
+}
+
+func TestBind_InvalidTarget(t *testing.T) {
+
+	// when
+	err := Bind(bindConfig{})
+
+	// then
+	if !errors.Is(err, ErrBindTarget) {
+		t.Errorf("Bind() error = '%v', want '%v'", err, ErrBindTarget)
+	}
+
+}
+
+func TestBind_RegisteredParsers(t *testing.T) {
+
+	// given
+	t.Setenv("BIND_TEST_ENDPOINT", "https://example.com")
+
+	actual := struct {
+		Endpoint url.URL    `env:"BIND_TEST_ENDPOINT"`
+		Address  net.IP     `env:"BIND_TEST_ADDRESS" default:"127.0.0.1"`
+		Level    slog.Level `env:"BIND_TEST_LEVEL" default:"debug"`
+		Ports    []int      `env:"BIND_TEST_PORTS" default:"80,443"`
+	}{}
+
+	// when
+	err := Bind(&actual, WithBindRegistry(NewRegistry()))
+
+	// then
+	if err != nil {
+		t.Fatalf("Bind() error = '%v'", err)
+	}
+
+	if actual.Endpoint.Host != "example.com" || !actual.Address.Equal(net.IPv4(127, 0, 0, 1)) ||
+		actual.Level != slog.LevelDebug || len(actual.Ports) != 2 {
+		t.Errorf("Bind() = '%+v'", actual)
+	}
+
+}
@@ -8,6 +8,10 @@ import (
 	"github.com/gookit/goutil/strutil"
 )
 
+// FirstBoolOr
+//
+// Deprecated: use GetOr[bool]. Unlike this helper, which skips the values it cannot parse,
+// GetOr fails with ErrPropertyInvalid on the first unparsable value.
 func FirstBoolOr(or bool) func(sources []Source) stdx.Value {
 	return func(sources []Source) stdx.Value {
 		result := stdx.NewValue(or)
@@ -25,6 +29,10 @@ func FirstBoolOr(or bool) func(sources []Source) stdx.Value {
 	}
 }
 
+// FirstInt
+//
+// Deprecated: use GetOr[int]. Unlike this helper, which skips the values it cannot parse,
+// GetOr fails with ErrPropertyInvalid on the first unparsable value.
 func FirstInt(or int) func(sources []Source) stdx.Value {
 	return func(sources []Source) stdx.Value {
 		result := stdx.NewValue(or)
@@ -42,6 +50,10 @@ func FirstInt(or int) func(sources []Source) stdx.Value {
 	}
 }
 
+// FirstInt64
+//
+// Deprecated: use GetOr[int64]. Unlike this helper, which skips the values it cannot parse,
+// GetOr fails with ErrPropertyInvalid on the first unparsable value.
 func FirstInt64(or int64) func(sources []Source) stdx.Value {
 	return func(sources []Source) stdx.Value {
 		result := stdx.NewValue(or)
@@ -59,6 +71,10 @@ func FirstInt64(or int64) func(sources []Source) stdx.Value {
 	}
 }
 
+// FirstDurationOr
+//
+// Deprecated: use GetOr[time.Duration]. Unlike this helper, which skips the values it cannot parse,
+// GetOr fails with ErrPropertyInvalid on the first unparsable value.
 func FirstDurationOr(or time.Duration) func(sources []Source) stdx.Value {
 	return func(sources []Source) stdx.Value {
 		result := stdx.NewValue(or)
@@ -76,6 +92,9 @@ func FirstDurationOr(or time.Duration) func(sources []Source) stdx.Value {
 	}
 }
 
+// FirstStringOr
+//
+// Deprecated: use GetOr[string] with the FirstPresent policy.
 func FirstStringOr(or string) func(sources []Source) string {
 	return func(sources []Source) string {
 		result := or
@@ -88,6 +107,9 @@ func FirstStringOr(or string) func(sources []Source) string {
 	}
 }
 
+// FirstStringNotEmpty
+//
+// Deprecated: use GetOr[string].
 func FirstStringNotEmpty(or string) func(sources []Source) string {
 	return func(sources []Source) string {
 		result := or
@@ -100,6 +122,9 @@ func FirstStringNotEmpty(or string) func(sources []Source) string {
 	}
 }
 
+// FirstStringNotEmptyElse
+//
+// Deprecated: use Get[string].
 func FirstStringNotEmptyElse() func(sources []Source) (string, error) {
 	return func(sources []Source) (string, error) {
 		var result string
@@ -112,6 +137,9 @@ func FirstStringNotEmptyElse() func(sources []Source) (string, error) {
 	}
 }
 
+// FirstStringNotEmptyThrow
+//
+// Deprecated: use MustGet[string].
 func FirstStringNotEmptyThrow() func(sources []Source) string {
 	return func(sources []Source) string {
 		for _, source := range sources {
@@ -3,10 +3,26 @@ package cfg
 import (
 	"errors"
 
-	"github.com/evgenivanovi/gpl/std"
-	"github.com/knadh/koanf/v2"
+	"github.com/evgenivanovi/gpl/stdx"
 )
 
-var cfg = koanf.New(std.Dot)
+var defaultRegistry = NewRegistry()
 
 var ErrPropertyNotFound = errors.New("property not found in sources")
+
+// ChangeHandler
+// Receives the previous and the current value of a key after a configuration file was reloaded.
+type ChangeHandler func(old, new stdx.Value)
+
+// OnChange
+// Registers a handler that is called every time the value of the key changes
+// after one of the watched configuration files was reloaded.
+func OnChange(key string, handler ChangeHandler) {
+	defaultRegistry.OnChange(key, handler)
+}
+
+// StopWatching
+// Stops watching configuration files for changes.
+func StopWatching() error {
+	return defaultRegistry.StopWatching()
+}
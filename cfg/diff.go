@@ -0,0 +1,101 @@
+package cfg
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/evgenivanovi/gpl/std"
+)
+
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// Difference
+// Describes a property resolved differently in two snapshots.
+type Difference struct {
+	Property string
+	Kind     ChangeKind
+	Left     SnapshotEntry
+	Right    SnapshotEntry
+}
+
+// Diff
+// Compares the resolved values of the properties in two snapshots, e.g. of two environments.
+// Properties resolved to the same value from different sources are considered equal,
+// secret properties are compared by their redacted values and thus only by presence.
+func Diff(left, right Snapshot) []Difference {
+
+	differences := make([]Difference, 0)
+
+	for _, l := range left {
+
+		r, ok := right.Lookup(l.Property)
+		if !l.Present && (!ok || !r.Present) {
+			continue
+		}
+
+		if l.Present && (!ok || !r.Present) {
+			differences = append(differences, Difference{Property: l.Property, Kind: ChangeRemoved, Left: l, Right: r})
+			continue
+		}
+
+		if !l.Present && r.Present {
+			differences = append(differences, Difference{Property: l.Property, Kind: ChangeAdded, Left: l, Right: r})
+			continue
+		}
+
+		if l.Value != r.Value {
+			differences = append(differences, Difference{Property: l.Property, Kind: ChangeModified, Left: l, Right: r})
+		}
+
+	}
+
+	for _, r := range right {
+		if _, ok := left.Lookup(r.Property); !ok && r.Present {
+			differences = append(differences, Difference{Property: r.Property, Kind: ChangeAdded, Right: r})
+		}
+	}
+
+	return differences
+
+}
+
+// WriteDiff
+// Writes a human-readable table of the differences with the values and sources on both sides.
+func WriteDiff(w io.Writer, differences []Difference) error {
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(table, "PROPERTY\tCHANGE\tLEFT\tRIGHT"); err != nil {
+		return err
+	}
+
+	for _, difference := range differences {
+
+		_, err := fmt.Fprintf(
+			table, "%s\t%s\t%s\t%s\n",
+			difference.Property, difference.Kind,
+			describeEntry(difference.Left), describeEntry(difference.Right),
+		)
+		if err != nil {
+			return err
+		}
+
+	}
+
+	return table.Flush()
+
+}
+
+func describeEntry(entry SnapshotEntry) string {
+	if !entry.Present {
+		return reportAbsent
+	}
+	return fmt.Sprintf("%s (%s)", entry.Value, std.If(entry.Source != std.Empty, entry.Source, std.Dash))
+}
@@ -0,0 +1,282 @@
+package cfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/evgenivanovi/gpl/std"
+	"github.com/evgenivanovi/gpl/stdx/net/http/headers"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+type Format string
+
+const (
+	FormatJSON  Format = "json"
+	FormatYAML  Format = "yaml"
+	FormatTable Format = "table"
+)
+
+const (
+	PrintConfigFlag = "print-config"
+	FormatParam     = "format"
+)
+
+var ErrDumpFormat = errors.New("dump format is not supported")
+
+// SnapshotEntry
+// Describes the resolved state of a single property in a serializable form,
+// the values of secret properties are redacted.
+type SnapshotEntry struct {
+	Property  string `json:"property" yaml:"property"`
+	Value     string `json:"value,omitempty" yaml:"value,omitempty"`
+	Present   bool   `json:"present" yaml:"present"`
+	Source    string `json:"source,omitempty" yaml:"source,omitempty"`
+	Kind      string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	Defaulted bool   `json:"defaulted" yaml:"defaulted"`
+	Secret    bool   `json:"secret,omitempty" yaml:"secret,omitempty"`
+	Error     string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Snapshot
+// Describes the resolved state of every property of a registry, sorted by property name.
+type Snapshot []SnapshotEntry
+
+// Lookup
+// Returns the entry of the property with the provided name.
+func (s Snapshot) Lookup(name string) (SnapshotEntry, bool) {
+	for _, entry := range s {
+		if entry.Property == name {
+			return entry, true
+		}
+	}
+	return SnapshotEntry{}, false
+}
+
+// Write
+// Renders the snapshot as JSON, YAML or a human-readable table.
+func (s Snapshot) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent(std.Empty, "  ")
+		return encoder.Encode(s)
+	case FormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(s); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case FormatTable:
+		return s.writeTable(w)
+	default:
+		return fmt.Errorf("%w: '%s'", ErrDumpFormat, format)
+	}
+}
+
+func (s Snapshot) writeTable(w io.Writer) error {
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(table, "PROPERTY\tVALUE\tSOURCE\tDEFAULT\tERROR"); err != nil {
+		return err
+	}
+
+	for _, entry := range s {
+
+		_, err := fmt.Fprintf(
+			table, "%s\t%s\t%s\t%t\t%s\n",
+			entry.Property,
+			std.If(entry.Present, entry.Value, reportAbsent),
+			std.If(entry.Present, entry.Source, std.Dash),
+			entry.Defaulted,
+			std.If(entry.Error != std.Empty, entry.Error, std.Dash),
+		)
+		if err != nil {
+			return err
+		}
+
+	}
+
+	return table.Flush()
+
+}
+
+// ReadSnapshot
+// Reads the snapshot written in the JSON or YAML format, e.g. dumped by another environment.
+func ReadSnapshot(r io.Reader) (Snapshot, error) {
+
+	snapshot := make(Snapshot, 0)
+
+	// YAML is a superset of JSON, so a single decoder reads both formats.
+	if err := yaml.NewDecoder(r).Decode(&snapshot); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	sortSnapshot(snapshot)
+	return snapshot, nil
+
+}
+
+// ReadSnapshotFile
+// Reads the snapshot from the file, see ReadSnapshot.
+func ReadSnapshotFile(path string) (Snapshot, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadSnapshot(file)
+
+}
+
+// TakeSnapshot
+// Captures the default registry, see Registry.Snapshot.
+func TakeSnapshot() Snapshot {
+	return defaultRegistry.Snapshot()
+}
+
+// Dump
+// Writes the snapshot of the default registry in the provided format.
+func Dump(w io.Writer, format Format) error {
+	return defaultRegistry.Dump(w, format)
+}
+
+// PrintConfig
+// Handles the print-config flag of the default registry, see Registry.PrintConfig.
+func PrintConfig(w io.Writer, ops ...ArgSourceOp) (bool, error) {
+	return defaultRegistry.PrintConfig(w, ops...)
+}
+
+// Handler
+// Serves the snapshot of the default registry, see Registry.Handler.
+func Handler() http.Handler {
+	return defaultRegistry.Handler()
+}
+
+// Snapshot
+// Resolves every registered property and captures its value, source and default status.
+func (r *Registry) Snapshot() Snapshot {
+
+	report := r.Report()
+	snapshot := make(Snapshot, 0, len(report))
+
+	for _, entry := range report {
+
+		item := SnapshotEntry{
+			Property:  entry.Property,
+			Present:   entry.Present,
+			Defaulted: entry.Defaulted,
+		}
+
+		if entry.Present {
+			item.Value = entry.Value
+			item.Source = entry.Source
+			item.Kind = sourceKind(entry.Source)
+		}
+
+		if property, ok := r.Lookup(entry.Property); ok {
+			item.Secret = property.IsSecret()
+		}
+
+		if entry.Err != nil {
+			item.Error = entry.Err.Error()
+		}
+
+		snapshot = append(snapshot, item)
+
+	}
+
+	sortSnapshot(snapshot)
+	return snapshot
+
+}
+
+// Dump
+// Writes the snapshot of the registry in the provided format.
+func (r *Registry) Dump(w io.Writer, format Format) error {
+	return r.Snapshot().Write(w, format)
+}
+
+// PrintConfig
+// Defines the print-config flag, by default in flag.CommandLine, and when it is set
+// writes the snapshot in the format given as its value, the table format when it is given without a value:
+//
+//	service --print-config=yaml
+//	service --print-config
+//
+// The format is given after '=', as the flag may be given without a value.
+// Reports whether the snapshot was written, so that the caller can exit instead of starting the service.
+func (r *Registry) PrintConfig(w io.Writer, ops ...ArgSourceOp) (bool, error) {
+
+	ops = append(
+		[]ArgSourceOp{
+			WithFlagKind(FlagOptional),
+			WithFlagUsage("print the resolved configuration (json, yaml or table) and exit"),
+		},
+		ops...,
+	)
+
+	format, set := NewArgSource(PrintConfigFlag, ops...).Get()
+	if !set {
+		return false, nil
+	}
+
+	if format == std.Empty || format == FlagOptionalValue {
+		format = string(FormatTable)
+	}
+
+	return true, r.Dump(w, Format(format))
+
+}
+
+// Handler
+// Serves the snapshot of the registry as JSON, YAML or a table selected by the 'format' query parameter,
+// JSON by default. The handler is intended for debug endpoints, secret values are redacted.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+
+		format := Format(req.URL.Query().Get(FormatParam))
+		if format == std.Empty {
+			format = FormatJSON
+		}
+
+		contentType := map[Format]string{
+			FormatJSON:  headers.TypeApplicationJSON.String(),
+			FormatYAML:  "application/yaml",
+			FormatTable: headers.TypeTextPlain.String(),
+		}
+
+		if _, ok := contentType[format]; !ok {
+			http.Error(w, fmt.Sprintf("%v: '%s'", ErrDumpFormat, format), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set(headers.ContentTypeKey.String(), contentType[format])
+		_ = r.Dump(w, format)
+
+	})
+}
+
+// sourceKind
+// Returns the kind of the source from its description, e.g. 'env' for 'env:PGHOST'.
+func sourceKind(source string) string {
+	kind, _, _ := strings.Cut(source, std.Colon)
+	return kind
+}
+
+func sortSnapshot(snapshot Snapshot) {
+	sort.SliceStable(snapshot, func(i, j int) bool {
+		return snapshot[i].Property < snapshot[j].Property
+	})
+}
@@ -0,0 +1,251 @@
+package cfg
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func dumpRegistry(t *testing.T, host string) *Registry {
+
+	t.Helper()
+	t.Setenv("DUMP_TEST_HOST", host)
+
+	registry := NewRegistry()
+	registry.Property("db.host", NewEnvSource("DUMP_TEST_HOST"), NewValueSource("localhost"))
+	registry.Property("db.port", NewValueSource("5432"))
+	registry.SecretProperty("db.password", NewValueSource("s3cr3t"))
+
+	return registry
+
+}
+
+func TestRegistry_Snapshot(t *testing.T) {
+
+	// given
+	registry := dumpRegistry(t, "db.internal")
+
+	// when
+	snapshot := registry.Snapshot()
+
+	// then
+	host, ok := snapshot.Lookup("db.host")
+	if !ok || host.Value != "db.internal" || host.Kind != "env" || host.Defaulted {
+		t.Errorf("Snapshot() db.host = %+v", host)
+	}
+
+	port, ok := snapshot.Lookup("db.port")
+	if !ok || port.Kind != "value" || !port.Defaulted {
+		t.Errorf("Snapshot() db.port = %+v", port)
+	}
+
+	password, ok := snapshot.Lookup("db.password")
+	if !ok || password.Value != Redacted || !password.Secret {
+		t.Errorf("Snapshot() db.password = %+v", password)
+	}
+
+}
+
+func TestSnapshot_WriteRead(t *testing.T) {
+
+	// given
+	snapshot := dumpRegistry(t, "db.internal").Snapshot()
+
+	for _, format := range []Format{FormatJSON, FormatYAML} {
+
+		buffer := &bytes.Buffer{}
+
+		// when
+		if err := snapshot.Write(buffer, format); err != nil {
+			t.Fatalf("Write(%s) unexpected error: %v", format, err)
+		}
+
+		read, err := ReadSnapshot(buffer)
+
+		// then
+		if err != nil {
+			t.Fatalf("ReadSnapshot(%s) unexpected error: %v", format, err)
+		}
+
+		if differences := Diff(snapshot, read); len(differences) != 0 {
+			t.Errorf("ReadSnapshot(%s) differences = %+v, want none", format, differences)
+		}
+
+	}
+
+	if err := snapshot.Write(&bytes.Buffer{}, "xml"); !errors.Is(err, ErrDumpFormat) {
+		t.Errorf("Write(xml) error = '%v', want ErrDumpFormat", err)
+	}
+
+}
+
+func TestDiff(t *testing.T) {
+
+	// given
+	left := Snapshot{
+		{Property: "db.host", Value: "localhost", Present: true},
+		{Property: "db.port", Value: "5432", Present: true},
+		{Property: "debug", Value: "true", Present: true},
+		{Property: "cache"},
+		{Property: "tls"},
+	}
+
+	right := Snapshot{
+		{Property: "db.host", Value: "db.internal", Present: true},
+		{Property: "db.port", Value: "5432", Present: true},
+		{Property: "mode", Value: "prod", Present: true},
+		{Property: "tls"},
+	}
+
+	// when
+	differences := Diff(left, right)
+
+	// then
+	kinds := make(map[string]ChangeKind)
+	for _, difference := range differences {
+		kinds[difference.Property] = difference.Kind
+	}
+
+	expected := map[string]ChangeKind{
+		"db.host": ChangeModified,
+		"debug":   ChangeRemoved,
+		"mode":    ChangeAdded,
+	}
+
+	if len(kinds) != len(expected) {
+		t.Fatalf("Diff() = %+v, want %v", differences, expected)
+	}
+
+	for property, kind := range expected {
+		if kinds[property] != kind {
+			t.Errorf("Diff() %s = '%s', want '%s'", property, kinds[property], kind)
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := WriteDiff(buffer, differences); err != nil {
+		t.Fatalf("WriteDiff() unexpected error: %v", err)
+	}
+
+	if !strings.Contains(buffer.String(), "localhost") || !strings.Contains(buffer.String(), "db.internal") {
+		t.Errorf("WriteDiff() = %s, want both values of db.host", buffer.String())
+	}
+
+}
+
+func TestRegistry_PrintConfig(t *testing.T) {
+
+	// given
+	registry := dumpRegistry(t, "db.internal")
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	buffer := &bytes.Buffer{}
+
+	printed, err := registry.PrintConfig(buffer, WithFlagSet(set))
+	if printed || err != nil {
+		t.Fatalf("PrintConfig() = %v, %v, want not printed before the flag is set", printed, err)
+	}
+
+	// when
+	if err := set.Parse([]string{"-" + PrintConfigFlag + "=yaml"}); err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	printed, err = registry.PrintConfig(buffer, WithFlagSet(set))
+
+	// then
+	if !printed || err != nil {
+		t.Fatalf("PrintConfig() = %v, %v, want printed", printed, err)
+	}
+
+	if !strings.Contains(buffer.String(), "property: db.host") || strings.Contains(buffer.String(), "s3cr3t") {
+		t.Errorf("PrintConfig() = %s", buffer.String())
+	}
+
+}
+
+func TestRegistry_PrintConfigWithoutValue(t *testing.T) {
+
+	goSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	pSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+
+	tests := []struct {
+		name  string
+		op    ArgSourceOp
+		parse func() error
+	}{
+		{
+			name:  "flag",
+			op:    WithFlagSet(goSet),
+			parse: func() error { return goSet.Parse([]string{"-" + PrintConfigFlag}) },
+		},
+		{
+			name:  "pflag",
+			op:    WithPFlagSet(pSet),
+			parse: func() error { return pSet.Parse([]string{"--" + PrintConfigFlag}) },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			// given
+			registry := dumpRegistry(t, "db.internal")
+			buffer := &bytes.Buffer{}
+
+			if printed, err := registry.PrintConfig(buffer, test.op); printed || err != nil {
+				t.Fatalf("PrintConfig() = %v, %v, want not printed before the flag is set", printed, err)
+			}
+
+			// when
+			if err := test.parse(); err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+
+			printed, err := registry.PrintConfig(buffer, test.op)
+
+			// then
+			if !printed || err != nil {
+				t.Fatalf("PrintConfig() = %v, %v, want printed", printed, err)
+			}
+
+			if !strings.Contains(buffer.String(), "PROPERTY") {
+				t.Errorf("PrintConfig() = %s, want the table", buffer.String())
+			}
+
+		})
+	}
+
+}
+
+func TestRegistry_Handler(t *testing.T) {
+
+	// given
+	handler := dumpRegistry(t, "db.internal").Handler()
+
+	// when
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/config", nil))
+
+	invalid := httptest.NewRecorder()
+	handler.ServeHTTP(invalid, httptest.NewRequest(http.MethodGet, "/config?format=xml", nil))
+
+	// then
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `"db.internal"`) {
+		t.Errorf("Handler() = %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	if strings.Contains(recorder.Body.String(), "s3cr3t") {
+		t.Errorf("Handler() exposed the secret value: %s", recorder.Body.String())
+	}
+
+	if invalid.Code != http.StatusBadRequest {
+		t.Errorf("Handler() = %d, want %d for unsupported format", invalid.Code, http.StatusBadRequest)
+	}
+
+}
@@ -0,0 +1,248 @@
+package cfg
+
+import (
+	"flag"
+
+	"github.com/evgenivanovi/gpl/std"
+	"github.com/evgenivanovi/gpl/std/conv"
+	"github.com/gookit/goutil/strutil"
+	"github.com/spf13/pflag"
+)
+
+type FlagKind int
+
+const (
+	FlagString FlagKind = iota
+	FlagBool
+	FlagInt
+	FlagDuration
+	// FlagOptional defines a string flag which may be given without a value, e.g. '--print-config',
+	// its value is FlagOptionalValue then.
+	FlagOptional
+)
+
+const FlagOptionalValue = "true"
+
+type ArgSourceOp func(*argOptions)
+
+// WithFlagSet
+// Binds the source to the provided flag set instead of flag.CommandLine.
+// The flag set is expected to be parsed by its owner.
+func WithFlagSet(set *flag.FlagSet) ArgSourceOp {
+	return func(o *argOptions) {
+		o.flags = goFlags{set: set}
+	}
+}
+
+// WithPFlagSet
+// Binds the source to the provided pflag flag set, e.g. the flags of a cobra command.
+// The flag set is expected to be parsed by its owner.
+func WithPFlagSet(set *pflag.FlagSet) ArgSourceOp {
+	return func(o *argOptions) {
+		o.flags = pFlags{set: set}
+	}
+}
+
+// WithFlagUsage
+// Sets the usage message of the defined flag.
+func WithFlagUsage(usage string) ArgSourceOp {
+	return func(o *argOptions) {
+		o.usage = usage
+	}
+}
+
+// WithFlagDefault
+// Sets the value returned when the flag is not set, it must be valid for the kind of the flag.
+func WithFlagDefault(value string) ArgSourceOp {
+	return func(o *argOptions) {
+		o.def = value
+	}
+}
+
+// WithFlagKind
+// Defines the flag as a typed flag, e.g. FlagBool allows '-debug' without a value.
+func WithFlagKind(kind FlagKind) ArgSourceOp {
+	return func(o *argOptions) {
+		o.kind = kind
+	}
+}
+
+// annotationDefault
+// Annotates a pflag flag defined by ArgSource with the default of the source.
+const annotationDefault = "cfg.arg.default"
+
+type argOptions struct {
+	flags flags
+	kind  FlagKind
+	def   string
+	usage string
+}
+
+// flags
+// Adapts flag.FlagSet and pflag.FlagSet to ArgSource.
+type flags interface {
+	// lookup returns the current and default values of the flag and whether it was set explicitly.
+	lookup(name string) (value string, def string, set bool, found bool)
+	define(name string, kind FlagKind, def string, usage string)
+	// parse parses the command line if the flag set is parsed lazily by ArgSource.
+	parse()
+	// defined returns the default of the source which defined the flag,
+	// typed flags report the zero value of their type as the default, e.g. "false",
+	// which must not shadow the sources consulted after the flag when it is reused.
+	defined(name string) (def string, found bool)
+}
+
+type goFlags struct {
+	set *flag.FlagSet
+}
+
+func (f goFlags) lookup(name string) (string, string, bool, bool) {
+
+	lookup := f.set.Lookup(name)
+	if lookup == nil {
+		return std.Empty, std.Empty, false, false
+	}
+
+	set := false
+	f.set.Visit(func(visited *flag.Flag) {
+		set = set || visited.Name == name
+	})
+
+	return lookup.Value.String(), lookup.DefValue, set, true
+
+}
+
+func (f goFlags) define(name string, kind FlagKind, def string, usage string) {
+
+	switch kind {
+	case FlagBool:
+		f.set.Bool(name, std.Must(parseFlagDefault(def, conv.MapBool)), usage)
+	case FlagInt:
+		f.set.Int(name, std.Must(parseFlagDefault(def, conv.MapInt)), usage)
+	case FlagDuration:
+		f.set.Duration(name, std.Must(parseFlagDefault(def, conv.MapDuration)), usage)
+	case FlagOptional:
+		f.set.Var(&optionalValue{value: def}, name, usage)
+	default:
+		f.set.String(name, def, usage)
+	}
+
+	lookup := f.set.Lookup(name)
+	lookup.Value = &definedValue{Value: lookup.Value, def: def}
+
+}
+
+func (f goFlags) defined(name string) (string, bool) {
+	if lookup := f.set.Lookup(name); lookup != nil {
+		if value, ok := lookup.Value.(*definedValue); ok {
+			return value.def, true
+		}
+	}
+	return std.Empty, false
+}
+
+// definedValue
+// Marks a flag defined by ArgSource with the default of the source.
+type definedValue struct {
+	flag.Value
+	def string
+}
+
+func (v *definedValue) String() string {
+	// flag.PrintDefaults calls String on the zero value.
+	if v.Value == nil {
+		return std.Empty
+	}
+	return v.Value.String()
+}
+
+func (v *definedValue) IsBoolFlag() bool {
+	value, ok := v.Value.(interface{ IsBoolFlag() bool })
+	return ok && value.IsBoolFlag()
+}
+
+func (v *definedValue) Get() any {
+	if getter, ok := v.Value.(flag.Getter); ok {
+		return getter.Get()
+	}
+	return v.String()
+}
+
+func (f goFlags) parse() {
+	if f.set == flag.CommandLine && !flag.Parsed() {
+		flag.Parse()
+	}
+}
+
+type pFlags struct {
+	set *pflag.FlagSet
+}
+
+func (f pFlags) lookup(name string) (string, string, bool, bool) {
+
+	lookup := f.set.Lookup(name)
+	if lookup == nil {
+		return std.Empty, std.Empty, false, false
+	}
+
+	return lookup.Value.String(), lookup.DefValue, lookup.Changed, true
+
+}
+
+func (f pFlags) define(name string, kind FlagKind, def string, usage string) {
+
+	switch kind {
+	case FlagBool:
+		f.set.Bool(name, std.Must(parseFlagDefault(def, conv.MapBool)), usage)
+	case FlagInt:
+		f.set.Int(name, std.Must(parseFlagDefault(def, conv.MapInt)), usage)
+	case FlagDuration:
+		f.set.Duration(name, std.Must(parseFlagDefault(def, conv.MapDuration)), usage)
+	case FlagOptional:
+		f.set.String(name, def, usage)
+		f.set.Lookup(name).NoOptDefVal = FlagOptionalValue
+	default:
+		f.set.String(name, def, usage)
+	}
+
+	_ = f.set.SetAnnotation(name, annotationDefault, []string{def})
+
+}
+
+// optionalValue
+// Value of a FlagOptional flag, the flag package sets boolean flags given without a value to "true".
+type optionalValue struct {
+	value string
+}
+
+func (v *optionalValue) String() string {
+	return v.value
+}
+
+func (v *optionalValue) Set(value string) error {
+	v.value = value
+	return nil
+}
+
+func (v *optionalValue) IsBoolFlag() bool {
+	return true
+}
+
+func (f pFlags) parse() {}
+
+func (f pFlags) defined(name string) (string, bool) {
+	if lookup := f.set.Lookup(name); lookup != nil {
+		if def, ok := lookup.Annotations[annotationDefault]; ok && len(def) == 1 {
+			return def[0], true
+		}
+	}
+	return std.Empty, false
+}
+
+func parseFlagDefault[T any](def string, mapping func(string) (T, error)) (T, error) {
+	if strutil.IsEmpty(def) {
+		var zero T
+		return zero, nil
+	}
+	return mapping(def)
+}
@@ -0,0 +1,212 @@
+package cfg
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestArgSource_FlagSet(t *testing.T) {
+
+	// given
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+
+	name := NewArgSource("name", WithFlagSet(set), WithFlagDefault("svc"))
+	empty := NewArgSource("empty", WithFlagSet(set))
+	debug := NewArgSource("debug", WithFlagSet(set), WithFlagKind(FlagBool))
+	timeout := NewArgSource("timeout", WithFlagSet(set), WithFlagKind(FlagDuration), WithFlagDefault("1s"))
+
+	// when
+	if err := set.Parse([]string{"-empty=", "-debug", "-timeout", "5s"}); err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	// then
+	if value, ok := name.Get(); !ok || value != "svc" {
+		t.Errorf("Get() = '%s', %v, want default 'svc', true", value, ok)
+	}
+
+	if value, ok := empty.Get(); !ok || value != "" {
+		t.Errorf("Get() = '%s', %v, want explicitly set empty value", value, ok)
+	}
+
+	if value, ok := debug.Get(); !ok || value != "true" {
+		t.Errorf("Get() = '%s', %v, want 'true', true", value, ok)
+	}
+
+	if value, ok := timeout.Get(); !ok || value != "5s" {
+		t.Errorf("Get() = '%s', %v, want '5s', true", value, ok)
+	}
+
+}
+
+func TestArgSource_NotSet(t *testing.T) {
+
+	// given
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	source := NewArgSource("port", WithFlagSet(set), WithFlagKind(FlagInt))
+
+	// when
+	if err := set.Parse(nil); err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	// then
+	if value, ok := source.Get(); ok {
+		t.Errorf("Get() = '%s', true, want absent", value)
+	}
+
+}
+
+func TestArgSource_Redefined(t *testing.T) {
+
+	// given
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	first := NewArgSource("mode", WithFlagSet(set))
+
+	// when
+	second := NewArgSource("mode", WithFlagSet(set))
+
+	if err := set.Parse([]string{"-mode", "prod"}); err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	// then
+	for _, source := range []*ArgSource{first, second} {
+		if value, ok := source.Get(); !ok || value != "prod" {
+			t.Errorf("Get() = '%s', %v, want 'prod', true", value, ok)
+		}
+	}
+
+}
+
+func TestArgSource_RedefinedTyped(t *testing.T) {
+
+	// given
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.Int("workers", 4, "")
+	NewArgSource("debug", WithFlagSet(set), WithFlagKind(FlagBool))
+
+	// when
+	debug := NewArgSource("debug", WithFlagSet(set), WithFlagKind(FlagBool))
+	workers := NewArgSource("workers", WithFlagSet(set), WithFlagKind(FlagInt))
+
+	if err := set.Parse(nil); err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	// then
+	if value, ok := debug.Get(); ok {
+		t.Errorf("Get() = '%s', true, want absent", value)
+	}
+
+	if value, ok := workers.Get(); !ok || value != "4" {
+		t.Errorf("Get() = '%s', %v, want the default of the flag '4', true", value, ok)
+	}
+
+}
+
+func TestArgSource_PFlagSet(t *testing.T) {
+
+	// given
+	set := pflag.NewFlagSet("test", pflag.ContinueOnError)
+
+	host := NewArgSource("host", WithPFlagSet(set))
+	verbose := NewArgSource("verbose", WithPFlagSet(set), WithFlagKind(FlagBool))
+	retries := NewArgSource("retries", WithPFlagSet(set), WithFlagKind(FlagInt), WithFlagDefault("3"))
+
+	// when
+	if err := set.Parse([]string{"--host=", "--verbose"}); err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	// then
+	if value, ok := host.Get(); !ok || value != "" {
+		t.Errorf("Get() = '%s', %v, want explicitly set empty value", value, ok)
+	}
+
+	if value, ok := verbose.Get(); !ok || value != "true" {
+		t.Errorf("Get() = '%s', %v, want 'true', true", value, ok)
+	}
+
+	if value, ok := retries.Get(); !ok || value != "3" {
+		t.Errorf("Get() = '%s', %v, want default '3', true", value, ok)
+	}
+
+}
+
+func TestBind_FlagSet(t *testing.T) {
+
+	// given
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+
+	actual := struct {
+		Debug bool   `flag:"debug" default:"false"`
+		Name  string `flag:"name" default:"svc"`
+	}{}
+
+	bind := func() error {
+		return Bind(&actual, WithBindRegistry(NewRegistry()), WithBindArgOps(WithFlagSet(set)))
+	}
+
+	if err := bind(); err != nil {
+		t.Fatalf("Bind() error = '%v'", err)
+	}
+
+	// when
+	if err := set.Parse([]string{"-debug", "-name", "api"}); err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	err := bind()
+
+	// then
+	if err != nil {
+		t.Fatalf("Bind() error = '%v'", err)
+	}
+
+	if !actual.Debug || actual.Name != "api" {
+		t.Errorf("Bind() = '%+v', want debug and name 'api'", actual)
+	}
+
+}
+
+func TestArgSource_RedefinedTypedPFlag(t *testing.T) {
+
+	// given
+	set := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	NewArgSource("debug", WithPFlagSet(set), WithFlagKind(FlagBool))
+
+	// when
+	debug := NewArgSource("debug", WithPFlagSet(set), WithFlagKind(FlagBool))
+
+	if err := set.Parse(nil); err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	// then
+	if value, ok := debug.Get(); ok {
+		t.Errorf("Get() = '%s', true, want absent", value)
+	}
+
+}
+
+func TestArgSource_DefinedBool(t *testing.T) {
+
+	// given
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	NewArgSource("debug", WithFlagSet(set), WithFlagKind(FlagBool))
+	debug := NewArgSource("debug", WithFlagSet(set), WithFlagKind(FlagBool))
+
+	// when
+	if err := set.Parse([]string{"-debug"}); err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	// then
+	if value, ok := debug.Get(); !ok || value != "true" {
+		t.Errorf("Get() = '%s', %v, want 'true', true", value, ok)
+	}
+
+}
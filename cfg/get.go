@@ -0,0 +1,154 @@
+package cfg
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/evgenivanovi/gpl/std"
+	"github.com/gookit/goutil/strutil"
+)
+
+// PropertyError
+// Describes why the value of a property could not be obtained.
+type PropertyError struct {
+	// Property is the name of the property.
+	Property string
+	// Source describes the source of the value, empty when no source provided a value.
+	Source string
+	// Err is ErrPropertyNotFound, ErrParserNotFound or the parsing error wrapping ErrPropertyInvalid.
+	Err error
+}
+
+func (e *PropertyError) Error() string {
+	if strutil.IsBlank(e.Source) {
+		return fmt.Sprintf("property '%s': %v", e.Property, e.Err)
+	}
+	return fmt.Sprintf("property '%s' from '%s': %v", e.Property, e.Source, e.Err)
+}
+
+func (e *PropertyError) Unwrap() error {
+	return e.Err
+}
+
+type GetOp func(*policy)
+
+// FirstPresent
+// Selects the value of the first source that provides one, even if it is blank.
+func FirstPresent() GetOp {
+	return func(p *policy) {
+		p.blank = true
+	}
+}
+
+// FirstNonBlank
+// Selects the value of the first source that provides a non-blank one, the default policy.
+func FirstNonBlank() GetOp {
+	return func(p *policy) {
+		p.blank = false
+	}
+}
+
+// Optional
+// Returns the zero value instead of ErrPropertyNotFound when no source provides a value.
+func Optional() GetOp {
+	return func(p *policy) {
+		p.optional = true
+	}
+}
+
+type policy struct {
+	blank    bool
+	optional bool
+}
+
+// Get
+// Selects the value among the sources of the property according to the policy
+// (FirstNonBlank by default) and parses it with the parser registered for T.
+// The value is required unless the Optional policy is used.
+// Errors are returned as *PropertyError.
+func Get[T any](property *Property, ops ...GetOp) (T, error) {
+
+	p := &policy{}
+	for _, op := range ops {
+		op(p)
+	}
+
+	value, found, err := get[T](property, p)
+	if err != nil || found || p.optional {
+		return value, err
+	}
+
+	return value, &PropertyError{
+		Property: property.name,
+		Err:      ErrPropertyNotFound,
+	}
+
+}
+
+// GetOr
+// Works as Get, but returns the default value when no source provides a value.
+func GetOr[T any](property *Property, or T, ops ...GetOp) (T, error) {
+
+	p := &policy{}
+	for _, op := range ops {
+		op(p)
+	}
+
+	value, found, err := get[T](property, p)
+	if err != nil || found {
+		return value, err
+	}
+
+	return or, nil
+
+}
+
+// MustGet
+// Works as Get, but panics on error.
+func MustGet[T any](property *Property, ops ...GetOp) T {
+	return std.Must(Get[T](property, ops...))
+}
+
+// get
+// Reads the sources once and reports whether any of them provided a value.
+func get[T any](property *Property, p *policy) (T, bool, error) {
+
+	var zero T
+
+	parser, ok := lookupParser(reflect.TypeFor[T]())
+	if !ok {
+		return zero, false, &PropertyError{
+			Property: property.name,
+			Err:      fmt.Errorf("%w: %s", ErrParserNotFound, reflect.TypeFor[T]()),
+		}
+	}
+
+	for _, source := range property.sources {
+
+		raw, present := source.Get()
+		if !present || !p.blank && strutil.IsBlank(raw) {
+			continue
+		}
+
+		value, err := parser(raw)
+		if err != nil {
+			// Parsers quote the input, the error of a secret is replaced not to reveal it.
+			if property.secret {
+				err = fmt.Errorf("value cannot be parsed as %s", reflect.TypeFor[T]().Kind())
+			}
+			return zero, true, &PropertyError{
+				Property: property.name,
+				Source:   describe(source),
+				Err:      fmt.Errorf("%w: %w", ErrPropertyInvalid, err),
+			}
+		}
+
+		// A parser of an interface type may return nil.
+		typed, _ := value.(T)
+		return typed, true, nil
+
+	}
+
+	return zero, false, nil
+
+}
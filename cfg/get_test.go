@@ -0,0 +1,244 @@
+package cfg
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGet(t *testing.T) {
+
+	// given
+	property := NewProperty(
+		"values",
+		NewValueSource(""),
+		NewValueSource("a, b,,c"),
+	)
+
+	// when
+	values, err := Get[[]string](&property)
+
+	// then
+	if err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(values, []string{"a", "b", "c"}) {
+		t.Errorf("Get() = %v, want [a b c]", values)
+	}
+
+}
+
+func TestGet_Types(t *testing.T) {
+
+	property := func(raw string) *Property {
+		prop := NewProperty("value", NewValueSource(raw))
+		return &prop
+	}
+
+	if value := MustGet[float64](property("1.5")); value != 1.5 {
+		t.Errorf("Get[float64]() = %v, want 1.5", value)
+	}
+
+	if value := MustGet[uint16](property("8080")); value != 8080 {
+		t.Errorf("Get[uint16]() = %v, want 8080", value)
+	}
+
+	if value := MustGet[time.Duration](property("5s")); value != 5*time.Second {
+		t.Errorf("Get[time.Duration]() = %v, want 5s", value)
+	}
+
+	if value := MustGet[time.Time](property("2024-01-02T03:04:05Z")); value.Year() != 2024 {
+		t.Errorf("Get[time.Time]() = %v, want year 2024", value)
+	}
+
+	if value := MustGet[*url.URL](property("https://example.com/path")); value.Host != "example.com" {
+		t.Errorf("Get[*url.URL]() host = %v, want example.com", value.Host)
+	}
+
+	if value := MustGet[net.IP](property("::1")); !value.Equal(net.IPv6loopback) {
+		t.Errorf("Get[net.IP]() = %v, want ::1", value)
+	}
+
+	if value := MustGet[slog.Level](property("warn")); value != slog.LevelWarn {
+		t.Errorf("Get[slog.Level]() = %v, want WARN", value)
+	}
+
+	if value := MustGet[[]int](property("1,2,3")); !reflect.DeepEqual(value, []int{1, 2, 3}) {
+		t.Errorf("Get[[]int]() = %v, want [1 2 3]", value)
+	}
+
+	expected := map[string]string{"a": "1", "b": "2"}
+	if value := MustGet[map[string]string](property("a=1, b=2")); !reflect.DeepEqual(value, expected) {
+		t.Errorf("Get[map[string]string]() = %v, want %v", value, expected)
+	}
+
+}
+
+func TestGet_Policies(t *testing.T) {
+
+	// given
+	property := NewProperty(
+		"mode",
+		NewValueSource(""),
+		NewValueSource("prod"),
+	)
+
+	absent := NewProperty("mode")
+
+	// when & then
+	if value := MustGet[string](&property, FirstPresent()); value != "" {
+		t.Errorf("Get(FirstPresent) = '%s', want empty", value)
+	}
+
+	if value := MustGet[string](&property, FirstNonBlank()); value != "prod" {
+		t.Errorf("Get(FirstNonBlank) = '%s', want 'prod'", value)
+	}
+
+	if _, err := Get[string](&absent); !errors.Is(err, ErrPropertyNotFound) {
+		t.Errorf("Get() error = '%v', want ErrPropertyNotFound", err)
+	}
+
+	if value, err := Get[int](&absent, Optional()); err != nil || value != 0 {
+		t.Errorf("Get(Optional) = %v, %v, want 0, nil", value, err)
+	}
+
+	if value, err := GetOr(&absent, "dev"); err != nil || value != "dev" {
+		t.Errorf("GetOr() = %v, %v, want 'dev', nil", value, err)
+	}
+
+	if value, err := GetOr(&property, "dev"); err != nil || value != "prod" {
+		t.Errorf("GetOr() = %v, %v, want 'prod', nil", value, err)
+	}
+
+}
+
+type countingSource struct {
+	*ValueSource
+	reads int
+}
+
+func (s *countingSource) Get() (string, bool) {
+	s.reads++
+	return s.ValueSource.Get()
+}
+
+func TestGetOr_ReadsSourcesOnce(t *testing.T) {
+
+	// given
+	absent := &countingSource{ValueSource: NewValueSource("")}
+	present := &countingSource{ValueSource: NewValueSource("8080")}
+	property := NewProperty("port", absent, present)
+
+	// when
+	value, err := GetOr(&property, 80)
+
+	// then
+	if err != nil || value != 8080 {
+		t.Errorf("GetOr() = %v, %v, want 8080, nil", value, err)
+	}
+
+	if absent.reads != 1 || present.reads != 1 {
+		t.Errorf("GetOr() reads = %d, %d, want every source read once", absent.reads, present.reads)
+	}
+
+}
+
+func TestGet_Errors(t *testing.T) {
+
+	// given
+	property := NewProperty("port", NewEnvSource("GET_TEST_PORT"))
+	t.Setenv("GET_TEST_PORT", "http")
+
+	// when
+	_, err := Get[int](&property)
+
+	// then
+	var propertyErr *PropertyError
+	if !errors.As(err, &propertyErr) {
+		t.Fatalf("Get() error = '%v', want *PropertyError", err)
+	}
+
+	if propertyErr.Property != "port" || propertyErr.Source != "env:GET_TEST_PORT" {
+		t.Errorf("Get() error = %+v, want property 'port' from 'env:GET_TEST_PORT'", propertyErr)
+	}
+
+	if !errors.Is(err, ErrPropertyInvalid) {
+		t.Errorf("Get() error = '%v', want ErrPropertyInvalid", err)
+	}
+
+	type unknown struct{}
+	if _, err := Get[unknown](&property); !errors.Is(err, ErrParserNotFound) {
+		t.Errorf("Get() error = '%v', want ErrParserNotFound", err)
+	}
+
+}
+
+func TestGet_SecretInvalid(t *testing.T) {
+
+	// given
+	property := NewSecretProperty("pin", NewEnvSource("GET_TEST_PIN"))
+	t.Setenv("GET_TEST_PIN", "s3cr3tPIN")
+
+	// when
+	_, err := Get[int](&property)
+
+	// then
+	if !errors.Is(err, ErrPropertyInvalid) {
+		t.Errorf("Get() error = '%v', want ErrPropertyInvalid", err)
+	}
+
+	if err != nil && strings.Contains(err.Error(), "s3cr3tPIN") {
+		t.Errorf("Get() error = '%v', reveals the secret", err)
+	}
+
+}
+
+type level int
+
+func TestRegisterParser(t *testing.T) {
+
+	// given
+	RegisterParser(func(raw string) (level, error) {
+		return level(len(strings.TrimSpace(raw))), nil
+	})
+
+	property := NewProperty("level", NewValueSource("xxx"))
+
+	// when
+	value, err := Get[level](&property)
+
+	// then
+	if err != nil || value != 3 {
+		t.Errorf("Get() = %v, %v, want 3, nil", value, err)
+	}
+
+}
+
+type stringer interface {
+	String() string
+}
+
+func TestRegisterParser_NilInterface(t *testing.T) {
+
+	// given
+	RegisterParser(func(string) (stringer, error) {
+		return nil, nil
+	})
+
+	property := NewProperty("stringer", NewValueSource("none"))
+
+	// when
+	value, err := Get[stringer](&property)
+
+	// then
+	if err != nil || value != nil {
+		t.Errorf("Get() = %v, %v, want nil, nil", value, err)
+	}
+
+}
@@ -1,14 +1,24 @@
 package cfg
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
+	"strings"
 
+	"github.com/knadh/koanf/parsers/dotenv"
+	"github.com/knadh/koanf/parsers/hcl"
 	"github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/toml/v2"
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/file"
 	"github.com/knadh/koanf/v2"
 )
 
+var ErrFormatUnsupported = errors.New("configuration file format is not supported")
+
+const dotenvName = ".env"
+
 func ReadFromFile(cfg *koanf.Koanf, path string, parser koanf.Parser) error {
 	if err := cfg.Load(file.Provider(path), parser); err != nil {
 		// Config file was found but another error was produced
@@ -24,3 +34,52 @@ func ReadFromJSONFile(cfg *koanf.Koanf, path string) error {
 func ReadFromYAMLFile(cfg *koanf.Koanf, path string) error {
 	return ReadFromFile(cfg, path, yaml.Parser())
 }
+
+func ReadFromTOMLFile(cfg *koanf.Koanf, path string) error {
+	return ReadFromFile(cfg, path, toml.Parser())
+}
+
+func ReadFromHCLFile(cfg *koanf.Koanf, path string) error {
+	return ReadFromFile(cfg, path, hcl.Parser(true))
+}
+
+func ReadFromDotenvFile(cfg *koanf.Koanf, path string) error {
+	return ReadFromFile(cfg, path, dotenv.Parser())
+}
+
+// ReadFromAnyFile
+// Reads the file with the parser detected by ParserFor.
+func ReadFromAnyFile(cfg *koanf.Koanf, path string) error {
+	parser, err := ParserFor(path)
+	if err != nil {
+		return err
+	}
+	return ReadFromFile(cfg, path, parser)
+}
+
+// ParserFor
+// Detects the format of the configuration file by its extension:
+// .json, .yaml, .yml, .toml, .hcl and .env (including '.env' and '.env.*' names).
+func ParserFor(path string) (koanf.Parser, error) {
+
+	name := strings.ToLower(filepath.Base(path))
+	if name == dotenvName || strings.HasPrefix(name, dotenvName+".") {
+		return dotenv.Parser(), nil
+	}
+
+	switch filepath.Ext(name) {
+	case ".json":
+		return json.Parser(), nil
+	case ".yaml", ".yml":
+		return yaml.Parser(), nil
+	case ".toml":
+		return toml.Parser(), nil
+	case ".hcl":
+		return hcl.Parser(true), nil
+	case ".env":
+		return dotenv.Parser(), nil
+	}
+
+	return nil, fmt.Errorf("%w: '%s'", ErrFormatUnsupported, path)
+
+}
@@ -0,0 +1,158 @@
+package cfg
+
+import (
+	"encoding"
+	"log/slog"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evgenivanovi/gpl/std"
+	"github.com/evgenivanovi/gpl/std/conv"
+	"github.com/gookit/goutil/strutil"
+	"github.com/pkg/errors"
+)
+
+var ErrParserNotFound = errors.New("parser is not registered for type")
+
+var parsers sync.Map
+
+func init() {
+
+	RegisterParser(func(raw string) (string, error) {
+		return raw, nil
+	})
+	RegisterParser(func(raw string) (Secret, error) {
+		return Secret(raw), nil
+	})
+
+	RegisterParser(conv.MapBool)
+	RegisterParser(conv.MapInt)
+	RegisterParser(conv.MapInt64)
+	RegisterParser(conv.MapUint)
+	RegisterParser(conv.MapUint16)
+	RegisterParser(conv.MapUint64)
+	RegisterParser(conv.MapFloat64)
+	RegisterParser(conv.MapDuration)
+
+	RegisterParser(func(raw string) (time.Time, error) {
+		return time.Parse(time.RFC3339, raw)
+	})
+
+	RegisterParser(parseURL)
+	RegisterParser(func(raw string) (url.URL, error) {
+		parsed, err := parseURL(raw)
+		if err != nil {
+			return url.URL{}, err
+		}
+		return *parsed, nil
+	})
+
+	RegisterParser(func(raw string) (net.IP, error) {
+		ip := net.ParseIP(strings.TrimSpace(raw))
+		if ip == nil {
+			return nil, errors.Errorf("value cannot be parsed as net.IP: '%s'", raw)
+		}
+		return ip, nil
+	})
+
+	RegisterParser(func(raw string) (slog.Level, error) {
+		var level slog.Level
+		err := level.UnmarshalText([]byte(raw))
+		return level, err
+	})
+
+	RegisterParser(func(raw string) ([]string, error) {
+		return splitList(raw), nil
+	})
+	RegisterParser(func(raw string) ([]int, error) {
+		return mapList(raw, conv.MapInt)
+	})
+	RegisterParser(func(raw string) ([]time.Duration, error) {
+		return mapList(raw, conv.MapDuration)
+	})
+
+	RegisterParser(parseMap)
+
+}
+
+// RegisterParser
+// Registers the parser used by Get, GetOr and Bind for values of type T,
+// a parser registered for the same type replaces the previous one.
+//
+// Types without a registered parser are parsed with encoding.TextUnmarshaler when *T implements it.
+func RegisterParser[T any](parser func(string) (T, error)) {
+	parsers.Store(
+		reflect.TypeFor[T](),
+		func(raw string) (any, error) {
+			return parser(raw)
+		},
+	)
+}
+
+func lookupParser(typ reflect.Type) (func(string) (any, error), bool) {
+
+	if parser, ok := parsers.Load(typ); ok {
+		return parser.(func(string) (any, error)), true
+	}
+
+	if reflect.PointerTo(typ).Implements(reflect.TypeFor[encoding.TextUnmarshaler]()) {
+		return func(raw string) (any, error) {
+			value := reflect.New(typ)
+			err := value.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+			return value.Elem().Interface(), err
+		}, true
+	}
+
+	return nil, false
+
+}
+
+func parseURL(raw string) (*url.URL, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return nil, errors.Errorf("value cannot be parsed as url.URL: %v", err)
+	}
+	return parsed, nil
+}
+
+// parseMap
+// Parses comma separated 'key=value' pairs.
+func parseMap(raw string) (map[string]string, error) {
+
+	result := make(map[string]string)
+
+	for _, pair := range splitList(raw) {
+
+		key, value, found := strings.Cut(pair, std.Equals)
+		if !found || strutil.IsBlank(key) {
+			return nil, errors.Errorf("value cannot be parsed as map: '%s' is not a 'key=value' pair", pair)
+		}
+
+		result[strings.TrimSpace(key)] = strings.TrimSpace(value)
+
+	}
+
+	return result, nil
+
+}
+
+func mapList[T any](raw string, mapping func(string) (T, error)) ([]T, error) {
+
+	items := splitList(raw)
+	result := make([]T, 0, len(items))
+
+	for _, item := range items {
+		value, err := mapping(item)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+
+	return result, nil
+
+}
@@ -0,0 +1,193 @@
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/evgenivanovi/gpl/std"
+	"github.com/gookit/goutil/strutil"
+)
+
+const (
+	ProfileLocal = "local"
+
+	DefaultProfileEnv  = "APP_PROFILE"
+	DefaultProfileFlag = "profile"
+)
+
+type ProfileOp func(*profiles)
+
+// WithProfile
+// Selects the profile explicitly, it takes precedence over the flag and the environment variable.
+func WithProfile(name string) ProfileOp {
+	return func(p *profiles) {
+		p.profile = name
+	}
+}
+
+// WithProfileEnv
+// Reads the profile from the environment variable, APP_PROFILE by default.
+func WithProfileEnv(key string) ProfileOp {
+	return func(p *profiles) {
+		p.env = key
+	}
+}
+
+// WithProfileFlag
+// Reads the profile from the command line flag, 'profile' by default.
+func WithProfileFlag(name string) ProfileOp {
+	return func(p *profiles) {
+		p.flag = name
+	}
+}
+
+// WithProfileArgOps
+// Configures the profile flag, e.g. binds it to the flag set of the command with WithPFlagSet.
+func WithProfileArgOps(ops ...ArgSourceOp) ProfileOp {
+	return func(p *profiles) {
+		p.args = append(p.args, ops...)
+	}
+}
+
+// WithoutLocalProfile
+// Skips the 'local' overlay, e.g. in production images.
+func WithoutLocalProfile() ProfileOp {
+	return func(p *profiles) {
+		p.local = false
+	}
+}
+
+// WithWatchedProfiles
+// Reloads the layers every time one of them is changed.
+func WithWatchedProfiles() ProfileOp {
+	return func(p *profiles) {
+		p.watch = true
+	}
+}
+
+type profiles struct {
+	profile string
+	env     string
+	flag    string
+	args    []ArgSourceOp
+	local   bool
+	watch   bool
+}
+
+func defaultProfiles() *profiles {
+	return &profiles{
+		env:   DefaultProfileEnv,
+		flag:  DefaultProfileFlag,
+		local: true,
+	}
+}
+
+func (p *profiles) resolve() string {
+
+	sources := make([]Source, 0)
+
+	if strutil.IsNotBlank(p.profile) {
+		sources = append(sources, NewValueSource(p.profile))
+	}
+
+	if strutil.IsNotBlank(p.flag) {
+		ops := append([]ArgSourceOp{WithFlagUsage("configuration profile")}, p.args...)
+		sources = append(sources, NewArgSource(p.flag, ops...))
+	}
+
+	if strutil.IsNotBlank(p.env) {
+		sources = append(sources, NewEnvSource(p.env))
+	}
+
+	property := NewProperty("profile", sources...)
+	return std.Must(GetOr(&property, std.Empty))
+
+}
+
+// LoadProfiles
+// Loads the layers into the default registry, see Registry.LoadProfiles.
+func LoadProfiles(base string, ops ...ProfileOp) (string, error) {
+	return defaultRegistry.LoadProfiles(base, ops...)
+}
+
+// LoadProfiles
+// Loads the base file and deep-merges the profile overlays on top of it:
+//
+//	config.yaml -> config.<profile>.yaml -> config.local.yaml
+//
+// The base file is required, the overlays are skipped when they do not exist.
+// Every layer is loaded once per registry, so a file source of the base file read later
+// does not put the base back on top of the overlays.
+// Returns the selected profile, use Origin to find out the layer every key came from
+// and NewKeySource to read the merged keys.
+func (r *Registry) LoadProfiles(base string, ops ...ProfileOp) (string, error) {
+
+	p := defaultProfiles()
+	for _, op := range ops {
+		op(p)
+	}
+
+	profile := p.resolve()
+
+	parser, err := ParserFor(base)
+	if err != nil {
+		return profile, err
+	}
+
+	if err = r.loadShared(base, parser); err != nil {
+		return profile, err
+	}
+
+	layers := []string{base}
+	for _, overlay := range overlays(base, profile, p.local) {
+
+		if _, err = os.Stat(overlay); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err = r.loadShared(overlay, parser); err != nil {
+			return profile, err
+		}
+
+		layers = append(layers, overlay)
+
+	}
+
+	if !p.watch {
+		return profile, nil
+	}
+
+	for _, layer := range layers {
+		if err = r.watch(layer); err != nil {
+			return profile, fmt.Errorf("error watching config file '%s': %w", layer, err)
+		}
+	}
+
+	return profile, nil
+
+}
+
+// overlays
+// Builds the overlay paths by inserting the profile before the extension of the base file.
+func overlays(base string, profile string, local bool) []string {
+
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+
+	result := make([]string, 0, 2)
+
+	if strutil.IsNotBlank(profile) && profile != ProfileLocal {
+		result = append(result, stem+std.Dot+profile+ext)
+	}
+
+	if local {
+		result = append(result, stem+std.Dot+ProfileLocal+ext)
+	}
+
+	return result
+
+}
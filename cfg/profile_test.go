@@ -0,0 +1,111 @@
+package cfg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegistry_LoadProfiles(t *testing.T) {
+
+	// given
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"config.yaml":       "db:\n  host: localhost\n  port: 5432\n  user: app\n",
+		"config.prod.yaml":  "db:\n  host: db.prod\n  port: 6432\n",
+		"config.local.yaml": "db:\n  port: 7432\n",
+		"config.dev.yaml":   "db:\n  host: db.dev\n",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv("PROFILE_TEST_PROFILE", "prod")
+
+	registry := NewRegistry()
+	base := filepath.Join(dir, "config.yaml")
+
+	// when
+	profile, err := registry.LoadProfiles(base, WithProfileEnv("PROFILE_TEST_PROFILE"))
+
+	// then
+	if err != nil {
+		t.Fatalf("LoadProfiles() error = '%v'", err)
+	}
+
+	if profile != "prod" {
+		t.Errorf("LoadProfiles() profile = '%v', want '%v'", profile, "prod")
+	}
+
+	expected := map[string][2]string{
+		"db.host": {"db.prod", "config.prod.yaml"},
+		"db.port": {"7432", "config.local.yaml"},
+		"db.user": {"app", "config.yaml"},
+	}
+
+	for key, want := range expected {
+
+		if actual, _ := registry.NewKeySource(key).Get(); actual != want[0] {
+			t.Errorf("Get('%s') = '%v', want '%v'", key, actual, want[0])
+		}
+
+		if origin, _ := registry.Origin(key); filepath.Base(origin) != want[1] {
+			t.Errorf("Origin('%s') = '%v', want '%v'", key, origin, want[1])
+		}
+
+	}
+
+	if layers := registry.Layers(); len(layers) != 3 {
+		t.Errorf("Layers() = '%v', want 3 layers", layers)
+	}
+
+}
+
+func TestRegistry_LoadProfilesThenBaseSource(t *testing.T) {
+
+	// given
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"config.yaml":      "db:\n  host: localhost\n",
+		"config.prod.yaml": "db:\n  host: db.prod\n",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registry := NewRegistry()
+	base := filepath.Join(dir, "config.yaml")
+
+	if _, err := registry.LoadProfiles(base, WithProfile("prod"), WithoutLocalProfile()); err != nil {
+		t.Fatalf("LoadProfiles() error = '%v'", err)
+	}
+
+	// when
+	actual := struct {
+		Host string `cfg:"db.host"`
+	}{}
+
+	err := Bind(&actual, WithBindRegistry(registry), WithBindFile(base))
+
+	// then
+	if err != nil {
+		t.Fatalf("Bind() error = '%v'", err)
+	}
+
+	if actual.Host != "db.prod" {
+		t.Errorf("Bind() host = '%v', want the overlay '%v'", actual.Host, "db.prod")
+	}
+
+	if origin, _ := registry.Origin("db.host"); filepath.Base(origin) != "config.prod.yaml" {
+		t.Errorf("Origin('db.host') = '%v', want '%v'", origin, "config.prod.yaml")
+	}
+
+}
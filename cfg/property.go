@@ -2,20 +2,116 @@ package cfg
 
 import (
 	"fmt"
+	"log/slog"
 
+	"github.com/evgenivanovi/gpl/std"
 	"github.com/evgenivanovi/gpl/stdx"
+	"github.com/evgenivanovi/gpl/stdx/mex"
+	"github.com/gookit/goutil/strutil"
+	me "github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
 )
 
 type Property struct {
 	name    string
 	sources []Source
+	rules   []Rule
+	secret  bool
 }
 
 func (p *Property) Name() string {
 	return p.name
 }
 
+// MarkSecret
+// Marks the property as secret, so its value is redacted in String, slog output,
+// reports and validation errors.
+func (p *Property) MarkSecret() *Property {
+	p.secret = true
+	return p
+}
+
+func (p *Property) IsSecret() bool {
+	return p.secret
+}
+
+func (p *Property) String() string {
+	return p.name + std.Equals + p.display(p.Resolve())
+}
+
+func (p *Property) LogValue() slog.Value {
+	return slog.StringValue(p.display(p.Resolve()))
+}
+
+func (p *Property) display(resolution Resolution) string {
+	if p.secret && resolution.Present {
+		return Redacted
+	}
+	return resolution.Value
+}
+
+// Resolution
+// Describes the value of a property and the source that provided it.
+type Resolution struct {
+	Value   string
+	Source  Source
+	Present bool
+}
+
+// Defaulted
+// Reports whether the value came from a ValueSource,
+// which conventionally closes the chain of sources as the fallback value.
+func (r Resolution) Defaulted() bool {
+	_, ok := r.Source.(*ValueSource)
+	return ok
+}
+
+// Resolve
+// Returns the first non-blank value among the sources of the property.
+func (p *Property) Resolve() Resolution {
+	for _, source := range p.sources {
+		if value, present := source.Get(); present && strutil.IsNotBlank(value) {
+			return Resolution{
+				Value:   value,
+				Source:  source,
+				Present: true,
+			}
+		}
+	}
+	return Resolution{}
+}
+
+// Constrain
+// Adds rules checked by Validate.
+func (p *Property) Constrain(rules ...Rule) *Property {
+	p.rules = append(p.rules, rules...)
+	return p
+}
+
+// Validate
+// Checks the resolved value of the property against all of its rules.
+func (p *Property) Validate() error {
+
+	resolution := p.Resolve()
+
+	errs := &me.Error{
+		ErrorFormat: mex.AppendFormat(std.SemicolonSpace),
+	}
+
+	for _, rule := range p.rules {
+		if err := rule(resolution.Value, resolution.Present); err != nil {
+			errs = me.Append(errs, std.If(p.secret, redact(err), err))
+		}
+	}
+
+	if err := errs.ErrorOrNil(); err != nil {
+		return fmt.Errorf("property '%s': %w", p.name, err)
+	}
+
+	return nil
+
+}
+
 func (p *Property) Calc(
 	calc func(sources []Source) string,
 ) string {
@@ -97,3 +193,14 @@ func NewProperty(
 		sources: sources,
 	}
 }
+
+func NewSecretProperty(
+	name string,
+	sources ...Source,
+) Property {
+	return Property{
+		name:    name,
+		sources: sources,
+		secret:  true,
+	}
+}
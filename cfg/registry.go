@@ -0,0 +1,362 @@
+package cfg
+
+import (
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/evgenivanovi/gpl/std"
+	"github.com/evgenivanovi/gpl/stdx"
+	slogx "github.com/evgenivanovi/gpl/stdx/log/slog"
+	"github.com/knadh/koanf/v2"
+)
+
+type loadedFile struct {
+	path   string
+	parser koanf.Parser
+}
+
+// state
+// Immutable snapshot of the merged configuration
+// together with the file every key was taken from.
+type state struct {
+	values  *koanf.Koanf
+	origins map[string]string
+}
+
+func newState() *state {
+	return &state{
+		values:  koanf.New(std.Dot),
+		origins: make(map[string]string),
+	}
+}
+
+// merge
+// Returns a new state with the file deep-merged on top of the current one.
+func (s *state) merge(path string, parser koanf.Parser) (*state, error) {
+
+	layer := koanf.New(std.Dot)
+	if err := ReadFromFile(layer, path, parser); err != nil {
+		return nil, err
+	}
+
+	values := s.values.Copy()
+	if err := values.Merge(layer); err != nil {
+		return nil, err
+	}
+
+	origins := maps.Clone(s.origins)
+	for _, key := range layer.Keys() {
+		origins[key] = path
+	}
+
+	return &state{
+		values:  values,
+		origins: origins,
+	}, nil
+
+}
+
+type notification struct {
+	handlers []ChangeHandler
+	old      stdx.Value
+	new      stdx.Value
+}
+
+// Registry
+// Owns the koanf state built from its configuration files, the change handlers
+// and the registered properties, so that independent configurations do not leak into each other.
+//
+// The state is never modified in place: every load or reload builds a new
+// koanf instance and swaps it atomically, so readers never observe a partial update.
+type Registry struct {
+	mu         sync.Mutex
+	state      atomic.Pointer[state]
+	files      []loadedFile
+	loads      map[string]func() error
+	handlers   map[string][]ChangeHandler
+	properties []*Property
+	watcher    *watcher
+}
+
+func NewRegistry() *Registry {
+	r := &Registry{
+		files:      make([]loadedFile, 0),
+		loads:      make(map[string]func() error),
+		handlers:   make(map[string][]ChangeHandler),
+		properties: make([]*Property, 0),
+	}
+	r.state.Store(newState())
+	return r
+}
+
+// Default
+// Returns the registry used by the package-level functions and constructors.
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register
+// Adds properties to the registry, a property with an already registered name replaces the previous one.
+func (r *Registry) Register(properties ...*Property) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, property := range properties {
+		replaced := false
+		for index, registered := range r.properties {
+			if registered.name == property.name {
+				r.properties[index] = property
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			r.properties = append(r.properties, property)
+		}
+	}
+
+}
+
+// Property
+// Creates a new property and registers it.
+func (r *Registry) Property(name string, sources ...Source) *Property {
+	property := NewProperty(name, sources...)
+	r.Register(&property)
+	return &property
+}
+
+// SecretProperty
+// Creates a new secret property and registers it.
+func (r *Registry) SecretProperty(name string, sources ...Source) *Property {
+	property := NewSecretProperty(name, sources...)
+	r.Register(&property)
+	return &property
+}
+
+// Lookup
+// Returns the registered property with the given name.
+func (r *Registry) Lookup(name string) (*Property, bool) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, property := range r.properties {
+		if property.name == name {
+			return property, true
+		}
+	}
+
+	return nil, false
+
+}
+
+// Properties
+// Returns the registered properties in the order of registration.
+func (r *Registry) Properties() []*Property {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Property(nil), r.properties...)
+}
+
+// OnChange
+// Registers a handler that is called every time the value of the key changes
+// after one of the watched configuration files was reloaded.
+func (r *Registry) OnChange(key string, handler ChangeHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[key] = append(r.handlers[key], handler)
+}
+
+// StopWatching
+// Stops watching configuration files of the registry for changes.
+func (r *Registry) StopWatching() error {
+
+	r.mu.Lock()
+	w := r.watcher
+	r.watcher = nil
+	r.mu.Unlock()
+
+	// The watcher is closed without the lock held,
+	// since a reload in progress is waiting for it.
+	if w == nil {
+		return nil
+	}
+
+	return w.close()
+
+}
+
+// Origin
+// Returns the path of the file the value of the key was taken from.
+func (r *Registry) Origin(key string) (string, bool) {
+	origin, ok := r.state.Load().origins[key]
+	return origin, ok
+}
+
+// Layers
+// Returns the paths of the loaded files in the order of precedence,
+// a file later in the list overrides the keys of the files before it.
+func (r *Registry) Layers() []string {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	layers := make([]string, 0, len(r.files))
+	for _, file := range r.files {
+		layers = append(layers, file.path)
+	}
+
+	return layers
+
+}
+
+func (r *Registry) get(key string) any {
+	return r.state.Load().values.Get(key)
+}
+
+func (r *Registry) load(path string, parser koanf.Parser) error {
+
+	r.mu.Lock()
+
+	next, err := r.state.Load().merge(path, parser)
+	if err != nil {
+		r.mu.Unlock()
+		return err
+	}
+
+	// A file loaded again is merged on top of the others,
+	// so it is moved to the end to keep the same precedence on reload.
+	files := make([]loadedFile, 0, len(r.files)+1)
+	for _, file := range r.files {
+		if file.path != path {
+			files = append(files, file)
+		}
+	}
+
+	r.files = append(files, loadedFile{path: path, parser: parser})
+	notifications := r.swap(next)
+
+	r.mu.Unlock()
+
+	notify(notifications)
+	return nil
+
+}
+
+// loadShared
+// Loads the file once for all the sources of the registry reading it,
+// so that the precedence of the file does not depend on which of them is read first.
+func (r *Registry) loadShared(path string, parser koanf.Parser) error {
+
+	r.mu.Lock()
+	load, found := r.loads[path]
+	if !found {
+		load = sync.OnceValue(func() error {
+			return r.load(path, parser)
+		})
+		r.loads[path] = load
+	}
+	r.mu.Unlock()
+
+	return load()
+
+}
+
+func (r *Registry) reload() error {
+
+	r.mu.Lock()
+
+	next := newState()
+	for _, file := range r.files {
+
+		if _, err := os.Stat(file.path); errors.Is(err, fs.ErrNotExist) {
+			slogx.Log().Debug("Configuration file disappeared, skipping", "path", file.path)
+			continue
+		}
+
+		merged, err := next.merge(file.path, file.parser)
+		if err != nil {
+			r.mu.Unlock()
+			return err
+		}
+
+		next = merged
+
+	}
+
+	notifications := r.swap(next)
+
+	r.mu.Unlock()
+
+	notify(notifications)
+	return nil
+
+}
+
+// swap
+// Replaces the current state and collects handlers of the keys whose values were changed.
+// Must be called with the lock held, the handlers must be called after the lock is released.
+func (r *Registry) swap(next *state) []notification {
+
+	prev := r.state.Swap(next)
+
+	notifications := make([]notification, 0)
+	for key, handlers := range r.handlers {
+
+		old, new := prev.values.Get(key), next.values.Get(key)
+		if reflect.DeepEqual(old, new) {
+			continue
+		}
+
+		notifications = append(notifications, notification{
+			handlers: append([]ChangeHandler(nil), handlers...),
+			old:      stdx.NewValue(old),
+			new:      stdx.NewValue(new),
+		})
+
+	}
+
+	return notifications
+
+}
+
+func (r *Registry) watch(path string) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.watcher == nil {
+		w, err := newWatcher(r.onFileChange)
+		if err != nil {
+			return err
+		}
+		r.watcher = w
+	}
+
+	return r.watcher.add(path)
+
+}
+
+func (r *Registry) onFileChange(path string) {
+	if err := r.reload(); err != nil {
+		slogx.Log().Warn(
+			"Could not reload configuration, keeping previous state",
+			"path", path,
+			slogx.ErrAttr(err),
+		)
+	}
+}
+
+func notify(notifications []notification) {
+	for _, notification := range notifications {
+		for _, handler := range notification.handlers {
+			handler(notification.old, notification.new)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package cfg
+
+import (
+	"testing"
+)
+
+func TestRegistry_Isolation(t *testing.T) {
+
+	// given
+	jsonRegistry := NewRegistry()
+	yamlRegistry := NewRegistry()
+
+	// when
+	jsonSource := jsonRegistry.NewJSONFileSource("type", ConfigJSON)
+	yamlSource := yamlRegistry.NewYAMLFileSource("type", ConfigYAML)
+
+	// then
+	if actual, _ := jsonSource.Get(); actual != "json" {
+		t.Errorf("Get() = '%v', want '%v'", actual, "json")
+	}
+
+	if actual, _ := yamlSource.Get(); actual != "yaml" {
+		t.Errorf("Get() = '%v', want '%v'", actual, "yaml")
+	}
+
+}
+
+func TestRegistry_Properties(t *testing.T) {
+
+	// given
+	registry := NewRegistry()
+
+	// when
+	registry.Property("first", NewValueSource("1"))
+	registry.Property("second", NewValueSource("2"))
+	registry.Property("first", NewValueSource("3"))
+
+	// then
+	properties := registry.Properties()
+	if len(properties) != 2 {
+		t.Fatalf("Properties() = '%v', want 2 properties", len(properties))
+	}
+
+	property, ok := registry.Lookup("first")
+	if !ok {
+		t.Fatal("Lookup() property 'first' not found")
+	}
+
+	if actual := property.Calc(FirstStringOr("")); actual != "3" {
+		t.Errorf("Calc() = '%v', want '%v'", actual, "3")
+	}
+
+}
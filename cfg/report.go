@@ -0,0 +1,91 @@
+package cfg
+
+import (
+	"io"
+
+	"github.com/evgenivanovi/gpl/std"
+	"github.com/evgenivanovi/gpl/stdx/mex"
+	me "github.com/hashicorp/go-multierror"
+)
+
+const reportAbsent = "<absent>"
+
+// ReportEntry
+// Describes the resolved state of a single property.
+type ReportEntry struct {
+	Property  string
+	Value     string
+	Source    string
+	Present   bool
+	Defaulted bool
+	Err       error
+}
+
+// Validate
+// Validates all properties registered in the default registry.
+func Validate() error {
+	return defaultRegistry.Validate()
+}
+
+// WriteReport
+// Writes the report of the default registry.
+func WriteReport(w io.Writer) error {
+	return defaultRegistry.WriteReport(w)
+}
+
+// Validate
+// Checks all registered properties against their rules
+// and returns a single error listing every violation.
+func (r *Registry) Validate() error {
+
+	errs := &me.Error{
+		ErrorFormat: mex.AppendFormat(std.SemicolonSpace),
+	}
+
+	for _, property := range r.Properties() {
+		if err := property.Validate(); err != nil {
+			errs = me.Append(errs, err)
+		}
+	}
+
+	return errs.ErrorOrNil()
+
+}
+
+// Report
+// Resolves and validates every registered property.
+func (r *Registry) Report() []ReportEntry {
+
+	properties := r.Properties()
+	entries := make([]ReportEntry, 0, len(properties))
+
+	for _, property := range properties {
+
+		resolution := property.Resolve()
+
+		entry := ReportEntry{
+			Property:  property.name,
+			Value:     property.display(resolution),
+			Present:   resolution.Present,
+			Defaulted: resolution.Defaulted(),
+			Err:       property.Validate(),
+		}
+
+		if resolution.Present {
+			entry.Source = describe(resolution.Source)
+		}
+
+		entries = append(entries, entry)
+
+	}
+
+	return entries
+
+}
+
+// WriteReport
+// Writes a human-readable table of every registered property:
+// its value, the source that provided it, whether it is defaulted and whether it is valid.
+func (r *Registry) WriteReport(w io.Writer) error {
+	return r.Snapshot().writeTable(w)
+}
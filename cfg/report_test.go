@@ -0,0 +1,85 @@
+package cfg
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Validate(t *testing.T) {
+
+	// given
+	registry := NewRegistry()
+
+	registry.Property("host").
+		Constrain(Required())
+	registry.Property("port", NewValueSource("70000")).
+		Constrain(Port())
+	registry.Property("timeout", NewValueSource("1m")).
+		Constrain(MaxDuration(30 * time.Second))
+	registry.Property("mode", NewValueSource("dev")).
+		Constrain(OneOf("dev", "prod"))
+	registry.Property("dsn", NewValueSource("host=localhost port=5432")).
+		Constrain(Required(), DSN())
+
+	// when
+	err := registry.Validate()
+
+	// then
+	if err == nil {
+		t.Fatal("Validate() expected error, got nil")
+	}
+
+	if !errors.Is(err, ErrPropertyNotFound) || !errors.Is(err, ErrPropertyInvalid) {
+		t.Errorf("Validate() error = '%v', want both not found and invalid errors", err)
+	}
+
+	for _, name := range []string{"host", "port", "timeout"} {
+		if !strings.Contains(err.Error(), "'"+name+"'") {
+			t.Errorf("Validate() error = '%v', does not mention property '%s'", err, name)
+		}
+	}
+
+	for _, name := range []string{"mode", "dsn"} {
+		if strings.Contains(err.Error(), "'"+name+"'") {
+			t.Errorf("Validate() error = '%v', mentions valid property '%s'", err, name)
+		}
+	}
+
+}
+
+func TestRegistry_WriteReport(t *testing.T) {
+
+	// given
+	t.Setenv("REPORT_TEST_HOST", "db.internal")
+
+	registry := NewRegistry()
+	registry.Property("host", NewEnvSource("REPORT_TEST_HOST"), NewValueSource("localhost"))
+	registry.Property("port", NewEnvSource("REPORT_TEST_PORT"), NewValueSource("5432"))
+
+	buffer := &bytes.Buffer{}
+
+	// when
+	err := registry.WriteReport(buffer)
+
+	// then
+	if err != nil {
+		t.Fatalf("WriteReport() error = '%v'", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("WriteReport() = '%v', want header and 2 rows", buffer.String())
+	}
+
+	if fields := strings.Fields(lines[1]); fields[1] != "db.internal" || fields[2] != "env:REPORT_TEST_HOST" || fields[3] != "false" {
+		t.Errorf("WriteReport() row = '%v'", lines[1])
+	}
+
+	if fields := strings.Fields(lines[2]); fields[1] != "5432" || fields[2] != "value" || fields[3] != "true" {
+		t.Errorf("WriteReport() row = '%v'", lines[2])
+	}
+
+}
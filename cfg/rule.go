@@ -0,0 +1,208 @@
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/evgenivanovi/gpl/std"
+	"github.com/evgenivanovi/gpl/std/conv"
+	"github.com/gookit/goutil/strutil"
+)
+
+var ErrPropertyInvalid = errors.New("property value is invalid")
+
+const (
+	PortMin = 0
+	PortMax = 65535
+)
+
+// Rule
+// Checks the resolved value of a property.
+// Present reports whether any of the sources provided a value at all,
+// all rules except Required accept an absent value.
+type Rule func(value string, present bool) error
+
+// Required
+// Rejects a property that none of the sources provided.
+func Required() Rule {
+	return func(_ string, present bool) error {
+		if !present {
+			return ErrPropertyNotFound
+		}
+		return nil
+	}
+}
+
+func MinInt(min int) Rule {
+	return IntRange(min, int(^uint(0)>>1))
+}
+
+func MaxInt(max int) Rule {
+	return IntRange(-int(^uint(0)>>1)-1, max)
+}
+
+func IntRange(min, max int) Rule {
+	return func(value string, present bool) error {
+
+		if !present {
+			return nil
+		}
+
+		actual, err := conv.MapInt(value)
+		if err != nil {
+			return invalid(err.Error())
+		}
+
+		if actual < min || actual > max {
+			return invalid(fmt.Sprintf("%d is out of range [%d, %d]", actual, min, max))
+		}
+
+		return nil
+
+	}
+}
+
+func MinDuration(min time.Duration) Rule {
+	return DurationRange(min, time.Duration(1<<63-1))
+}
+
+func MaxDuration(max time.Duration) Rule {
+	return DurationRange(time.Duration(-1<<63), max)
+}
+
+func DurationRange(min, max time.Duration) Rule {
+	return func(value string, present bool) error {
+
+		if !present {
+			return nil
+		}
+
+		actual, err := conv.MapDuration(value)
+		if err != nil {
+			return invalid(err.Error())
+		}
+
+		if actual < min || actual > max {
+			return invalid(fmt.Sprintf("%s is out of range [%s, %s]", actual, min, max))
+		}
+
+		return nil
+
+	}
+}
+
+// OneOf
+// Accepts only one of the provided values.
+func OneOf(values ...string) Rule {
+	return func(value string, present bool) error {
+
+		if !present {
+			return nil
+		}
+
+		for _, allowed := range values {
+			if value == allowed {
+				return nil
+			}
+		}
+
+		return invalid(fmt.Sprintf("'%s' is not one of [%s]", value, strings.Join(values, std.CommaSpace)))
+
+	}
+}
+
+// Matches
+// Accepts only values matching the regular expression, panics if the expression is invalid.
+func Matches(pattern string) Rule {
+	expression := regexp.MustCompile(pattern)
+	return func(value string, present bool) error {
+
+		if !present {
+			return nil
+		}
+
+		if !expression.MatchString(value) {
+			return invalid(fmt.Sprintf("'%s' does not match '%s'", value, pattern))
+		}
+
+		return nil
+
+	}
+}
+
+// Port
+// Accepts a TCP/UDP port number, 0 stands for an ephemeral port.
+func Port() Rule {
+	return IntRange(PortMin, PortMax)
+}
+
+// URL
+// Accepts an absolute URL, optionally restricted to the provided schemes.
+func URL(schemes ...string) Rule {
+	return func(value string, present bool) error {
+
+		if !present {
+			return nil
+		}
+
+		parsed, err := url.Parse(value)
+		if err != nil {
+			return invalid(err.Error())
+		}
+
+		if strutil.IsBlank(parsed.Scheme) || strutil.IsBlank(parsed.Host) {
+			return invalid(fmt.Sprintf("'%s' is not an absolute url", value))
+		}
+
+		if len(schemes) == 0 {
+			return nil
+		}
+
+		for _, scheme := range schemes {
+			if parsed.Scheme == scheme {
+				return nil
+			}
+		}
+
+		return invalid(fmt.Sprintf("scheme '%s' is not one of [%s]", parsed.Scheme, strings.Join(schemes, std.CommaSpace)))
+
+	}
+}
+
+// DSN
+// Accepts a data source name either in the URL form (postgres://user@host/db)
+// or in the keyword/value form (host=localhost port=5432).
+func DSN() Rule {
+	return func(value string, present bool) error {
+
+		if !present {
+			return nil
+		}
+
+		if strings.Contains(value, "://") {
+			return URL()(value, present)
+		}
+
+		for _, pair := range strings.Fields(value) {
+			key, _, found := strings.Cut(pair, std.Equals)
+			if !found || strutil.IsBlank(key) {
+				return invalid(fmt.Sprintf("'%s' is not a 'key=value' pair", pair))
+			}
+		}
+
+		if len(strings.Fields(value)) == 0 {
+			return invalid("dsn is empty")
+		}
+
+		return nil
+
+	}
+}
+
+func invalid(msg string) error {
+	return fmt.Errorf("%w: %s", ErrPropertyInvalid, msg)
+}
@@ -0,0 +1,166 @@
+package cfg
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/evgenivanovi/gpl/std"
+	"github.com/evgenivanovi/gpl/stdx"
+	"github.com/gookit/goutil/strutil"
+)
+
+// Redacted
+// Replaces the value of a secret wherever it could be printed.
+const Redacted = "******"
+
+// EnvFileSuffix
+// Suffix of the environment variable holding the path of a file with the value,
+// the convention used by Docker and Kubernetes secrets (PGPASSWORD_FILE for PGPASSWORD).
+const EnvFileSuffix = "_FILE"
+
+// Secret
+// Holds a sensitive value that is never printed, logged or marshalled as is.
+// Use Reveal to get the actual value.
+type Secret string
+
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+func (s Secret) IsEmpty() bool {
+	return s == std.Empty
+}
+
+func (s Secret) String() string {
+	return Redacted
+}
+
+func (s Secret) GoString() string {
+	return Redacted
+}
+
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(Redacted)
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Redacted)
+}
+
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(Redacted), nil
+}
+
+// FileContentSource
+// Reads the value from a file, e.g. a Docker or Kubernetes secret mount.
+// The file is read on every call, so rotated secrets are picked up,
+// trailing newlines are trimmed.
+type FileContentSource struct {
+	path string
+}
+
+func (s FileContentSource) Get() (string, bool) {
+	return readFileContent(s.path)
+}
+
+func (s FileContentSource) Map(mapping func(string) (any, error)) stdx.Value {
+
+	value, ok := s.Get()
+	if !ok {
+		return stdx.NewValue(nil)
+	}
+
+	val, err := mapping(value)
+	if err != nil {
+		return stdx.NewNilValue(err)
+	}
+
+	return stdx.NewValue(val)
+
+}
+
+func (s FileContentSource) String() string {
+	return "file:" + s.path
+}
+
+func NewFileContentSource(path string) *FileContentSource {
+
+	if strutil.IsEmpty(path) {
+		panic("path is required")
+	}
+
+	return &FileContentSource{
+		path: path,
+	}
+
+}
+
+// EnvFileSource
+// Reads the value from the file whose path is stored in the KEY_FILE environment variable.
+type EnvFileSource struct {
+	key string
+}
+
+func (s EnvFileSource) Get() (string, bool) {
+
+	path, ok := os.LookupEnv(s.key + EnvFileSuffix)
+	if !ok || strutil.IsBlank(path) {
+		return std.Empty, false
+	}
+
+	return readFileContent(path)
+
+}
+
+func (s EnvFileSource) Map(mapping func(string) (any, error)) stdx.Value {
+
+	value, ok := s.Get()
+	if !ok {
+		return stdx.NewValue(nil)
+	}
+
+	val, err := mapping(value)
+	if err != nil {
+		return stdx.NewNilValue(err)
+	}
+
+	return stdx.NewValue(val)
+
+}
+
+func (s EnvFileSource) String() string {
+	return "env:" + s.key + EnvFileSuffix
+}
+
+func NewEnvFileSource(key string) *EnvFileSource {
+
+	if strutil.IsEmpty(key) {
+		panic("key is required")
+	}
+
+	return &EnvFileSource{
+		key: key,
+	}
+
+}
+
+func readFileContent(path string) (string, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return std.Empty, false
+	}
+	return strings.TrimRight(string(content), "\r\n"), true
+}
+
+// redact
+// Keeps the kind of the rule violation but drops the message,
+// which may contain the secret value.
+func redact(err error) error {
+	if errors.Is(err, ErrPropertyNotFound) {
+		return ErrPropertyNotFound
+	}
+	return ErrPropertyInvalid
+}
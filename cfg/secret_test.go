@@ -0,0 +1,145 @@
+package cfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecret_Redacted(t *testing.T) {
+
+	// given
+	secret := Secret("password")
+
+	buffer := &bytes.Buffer{}
+	log := slog.New(slog.NewTextHandler(buffer, nil))
+
+	// when
+	log.Info("secret", "value", secret)
+	marshalled, _ := json.Marshal(map[string]Secret{"value": secret})
+	printed := fmt.Sprintf("%v %s %#v", secret, secret, secret)
+
+	// then
+	for _, actual := range []string{buffer.String(), string(marshalled), printed} {
+		if strings.Contains(actual, "password") {
+			t.Errorf("Secret is not redacted in '%v'", actual)
+		}
+	}
+
+	if secret.Reveal() != "password" {
+		t.Errorf("Reveal() = '%v', want '%v'", secret.Reveal(), "password")
+	}
+
+}
+
+func TestEnvFileSource(t *testing.T) {
+
+	// given
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte("password\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("SECRET_TEST_PASSWORD_FILE", path)
+
+	// when
+	actual, present := NewEnvFileSource("SECRET_TEST_PASSWORD").Get()
+
+	// then
+	if !present || actual != "password" {
+		t.Errorf("Get() = '%v', '%v', want '%v', 'true'", actual, present, "password")
+	}
+
+}
+
+func TestSecretProperty_Report(t *testing.T) {
+
+	// given
+	registry := NewRegistry()
+	registry.SecretProperty("db.password", NewValueSource("s3cr3t")).
+		Constrain(OneOf("other"))
+
+	buffer := &bytes.Buffer{}
+
+	// when
+	err := registry.WriteReport(buffer)
+	property, _ := registry.Lookup("db.password")
+
+	// then
+	if err != nil {
+		t.Fatalf("WriteReport() error = '%v'", err)
+	}
+
+	for _, actual := range []string{buffer.String(), property.String(), registry.Validate().Error()} {
+		if strings.Contains(actual, "s3cr3t") {
+			t.Errorf("Secret property is not redacted in '%v'", actual)
+		}
+	}
+
+}
+
+func TestBind_Secret(t *testing.T) {
+
+	// given
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte("password\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("BIND_SECRET_TEST_PASSWORD_FILE", path)
+
+	target := struct {
+		Password Secret `env:"BIND_SECRET_TEST_PASSWORD"`
+	}{}
+
+	registry := NewRegistry()
+
+	// when
+	err := Bind(&target, WithBindRegistry(registry))
+
+	// then
+	if err != nil {
+		t.Fatalf("Bind() error = '%v'", err)
+	}
+
+	if target.Password.Reveal() != "password" {
+		t.Errorf("Bind() = '%v', want '%v'", target.Password.Reveal(), "password")
+	}
+
+	if property, _ := registry.Lookup("BIND_SECRET_TEST_PASSWORD"); !property.IsSecret() {
+		t.Error("Bind() property is not marked as secret")
+	}
+
+}
+
+func TestBind_SecretInvalid(t *testing.T) {
+
+	// given
+	t.Setenv("BIND_SECRET_TEST_PIN", "s3cr3tPIN")
+
+	target := struct {
+		Pin int `env:"BIND_SECRET_TEST_PIN" secret:"true"`
+	}{}
+
+	// when
+	err := Bind(&target, WithBindRegistry(NewRegistry()))
+
+	// then
+	if err == nil {
+		t.Fatal("Bind() expected error, got nil")
+	}
+
+	if strings.Contains(err.Error(), "s3cr3tPIN") {
+		t.Errorf("Bind() error = '%v', reveals the secret", err)
+	}
+
+	if !strings.Contains(err.Error(), "cannot be parsed as int") {
+		t.Errorf("Bind() error = '%v', does not describe the failure", err)
+	}
+
+}
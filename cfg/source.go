@@ -11,6 +11,12 @@ import (
 	"github.com/evgenivanovi/gpl/stdx"
 	slogx "github.com/evgenivanovi/gpl/stdx/log/slog"
 	"github.com/gookit/goutil/strutil"
+	"github.com/knadh/koanf/parsers/dotenv"
+	"github.com/knadh/koanf/parsers/hcl"
+	"github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/toml/v2"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/v2"
 )
 
 type Source interface {
@@ -42,6 +48,10 @@ func (s ValueSource) Map(mapping func(string) (any, error)) stdx.Value {
 
 }
 
+func (s ValueSource) String() string {
+	return "value"
+}
+
 func NewValueSource(value string) *ValueSource {
 	return &ValueSource{
 		value: value,
@@ -49,27 +59,34 @@ func NewValueSource(value string) *ValueSource {
 }
 
 type ArgSource struct {
-	key string
+	key   string
+	def   string
+	flags flags
 }
 
+// Get
+// Returns the value of the flag if it was set explicitly, even if it is empty,
+// otherwise returns the default value, which is absent when empty.
+//
+// A source bound to flag.CommandLine parses the command line on first use.
 func (s ArgSource) Get() (string, bool) {
 
-	if !flag.Parsed() {
-		flag.Parse()
-	}
-
-	lookup := flag.Lookup(s.key)
+	s.flags.parse()
 
-	if lookup == nil {
+	value, _, set, found := s.flags.lookup(s.key)
+	if !found {
 		return "", false
 	}
 
-	value := lookup.Value.String()
-	if value == "" {
+	if set {
+		return value, true
+	}
+
+	if s.def == "" {
 		return "", false
 	}
 
-	return value, true
+	return s.def, true
 
 }
 
@@ -89,24 +106,49 @@ func (s ArgSource) Map(mapping func(string) (any, error)) stdx.Value {
 
 }
 
-func NewArgSource(key string) *ArgSource {
-	return NewArgSourceWithOps(key, "", "")
+func (s ArgSource) String() string {
+	return "arg:" + s.key
 }
 
-func NewArgSourceWithOps(key, onAbsence, usage string) *ArgSource {
+// NewArgSource
+// Creates the source of the flag with the provided name, defining the flag
+// in flag.CommandLine or in the flag set provided by WithFlagSet or WithPFlagSet.
+// A flag already defined in the flag set is reused with its own kind and default value.
+func NewArgSource(key string, ops ...ArgSourceOp) *ArgSource {
 
 	if strutil.IsEmpty(key) {
 		panic("key is required")
 	}
 
-	flag.String(key, onAbsence, usage)
+	options := &argOptions{
+		flags: goFlags{set: flag.CommandLine},
+	}
+
+	for _, op := range ops {
+		op(options)
+	}
+
+	def := options.def
+	if _, current, _, found := options.flags.lookup(key); !found {
+		options.flags.define(key, options.kind, options.def, options.usage)
+	} else if own, ok := options.flags.defined(key); ok {
+		def = own
+	} else {
+		def = current
+	}
 
 	return &ArgSource{
-		key: key,
+		key:   key,
+		def:   def,
+		flags: options.flags,
 	}
 
 }
 
+func NewArgSourceWithOps(key, onAbsence, usage string) *ArgSource {
+	return NewArgSource(key, WithFlagDefault(onAbsence), WithFlagUsage(usage))
+}
+
 type EnvSource struct {
 	key string
 }
@@ -131,6 +173,10 @@ func (s EnvSource) Map(mapping func(string) (any, error)) stdx.Value {
 
 }
 
+func (s EnvSource) String() string {
+	return "env:" + s.key
+}
+
 func NewEnvSource(key string) *EnvSource {
 
 	if strutil.IsEmpty(key) {
@@ -143,6 +189,64 @@ func NewEnvSource(key string) *EnvSource {
 
 }
 
+// KeySource
+// Reads the key from the files already loaded into the registry, e.g. by LoadProfiles.
+type KeySource struct {
+	key      string
+	registry *Registry
+}
+
+func (s KeySource) Get() (string, bool) {
+
+	value := s.registry.get(s.key)
+	if value == nil {
+		return std.Empty, false
+	}
+
+	return stdx.NewValue(value).String(), true
+
+}
+
+func (s KeySource) Map(mapping func(string) (any, error)) stdx.Value {
+
+	value, ok := s.Get()
+	if !ok {
+		return stdx.NewValue(nil)
+	}
+
+	val, err := mapping(value)
+	if err != nil {
+		return stdx.NewNilValue(err)
+	}
+
+	return stdx.NewValue(val)
+
+}
+
+func (s KeySource) String() string {
+	if origin, ok := s.registry.Origin(s.key); ok {
+		return "file:" + origin + "#" + s.key
+	}
+	return "key:" + s.key
+}
+
+func NewKeySource(key string) *KeySource {
+	return defaultRegistry.NewKeySource(key)
+}
+
+func (r *Registry) NewKeySource(key string) *KeySource {
+
+	if strutil.IsEmpty(key) {
+		panic("key is required")
+	}
+
+	return &KeySource{
+		key:      key,
+		registry: r,
+	}
+
+}
+
 type FileSourceOp func(*FileSource)
 
 func WithKey(key string) FileSourceOp {
@@ -169,6 +273,12 @@ func WithPathFn(path func() string) FileSourceOp {
 	}
 }
 
+func withRegistry(registry *Registry) FileSourceOp {
+	return func(source *FileSource) {
+		source.registry = registry
+	}
+}
+
 func withOnce(fn func()) FileSourceOp {
 	return func(source *FileSource) {
 		source.once = sync.OnceFunc(fn)
@@ -176,16 +286,17 @@ func withOnce(fn func()) FileSourceOp {
 }
 
 type FileSource struct {
-	key  string
-	path func() string
-	once func()
+	key      string
+	path     func() string
+	once     func()
+	registry *Registry
 }
 
 func (s FileSource) Get() (string, bool) {
 
 	s.once()
 
-	value := cfg.Get(s.key)
+	value := s.registry.get(s.key)
 	if value == nil {
 		return std.Empty, false
 	}
@@ -210,82 +321,174 @@ func (s FileSource) Map(mapping func(string) (any, error)) stdx.Value {
 
 }
 
+// String
+// Describes the file the value was actually taken from,
+// which differs from the own file of the source when another file overrides the key.
+func (s FileSource) String() string {
+	if origin, ok := s.registry.Origin(s.key); ok {
+		return "file:" + origin + "#" + s.key
+	}
+	return "file:" + s.path() + "#" + s.key
+}
+
 func NewJSONFileSource(key string, path string) *FileSource {
+	return defaultRegistry.NewJSONFileSource(key, path)
+}
 
-	once := func() {
+func NewJSONFileSourceWithPath(key string, path func() string) *FileSource {
+	return defaultRegistry.NewJSONFileSourceWithPath(key, path)
+}
 
-		if strutil.IsBlank(path) {
-			msg := fmt.Sprintf("filepath is empty, not able to find value for key='%s'", key)
-			slogx.Log().Debug(msg)
-			return
-		}
+// NewWatchedJSONFileSource
+// Creates a JSON file source that re-reads the file every time it is changed.
+// Use OnChange to get notified about the changed values.
+func NewWatchedJSONFileSource(key string, path string) *FileSource {
+	return defaultRegistry.NewWatchedJSONFileSource(key, path)
+}
 
-		err := ReadFromJSONFile(cfg, path)
-		if err != nil {
-			slogx.Log().Debug(err.Error())
-		}
+func NewYAMLFileSource(key string, path string) *FileSource {
+	return defaultRegistry.NewYAMLFileSource(key, path)
+}
 
-	}
+func NewYAMLFileSourceWithPath(key string, path func() string) *FileSource {
+	return defaultRegistry.NewYAMLFileSourceWithPath(key, path)
+}
 
-	return newFileSourceWithOp(
-		WithKey(key),
-		WithPath(path),
-		withOnce(once),
-	)
+// NewWatchedYAMLFileSource
+// Creates a YAML file source that re-reads the file every time it is changed.
+// Use OnChange to get notified about the changed values.
+func NewWatchedYAMLFileSource(key string, path string) *FileSource {
+	return defaultRegistry.NewWatchedYAMLFileSource(key, path)
+}
 
+func NewTOMLFileSource(key string, path string) *FileSource {
+	return defaultRegistry.NewTOMLFileSource(key, path)
 }
 
-func NewJSONFileSourceWithPath(key string, path func() string) *FileSource {
+func NewTOMLFileSourceWithPath(key string, path func() string) *FileSource {
+	return defaultRegistry.NewTOMLFileSourceWithPath(key, path)
+}
 
-	once := func() {
+func NewWatchedTOMLFileSource(key string, path string) *FileSource {
+	return defaultRegistry.NewWatchedTOMLFileSource(key, path)
+}
 
-		if strutil.IsBlank(path()) {
-			msg := fmt.Sprintf("filepath is empty, not able to find value for key='%s'", key)
-			slogx.Log().Debug(msg)
-			return
-		}
+func NewHCLFileSource(key string, path string) *FileSource {
+	return defaultRegistry.NewHCLFileSource(key, path)
+}
 
-		err := ReadFromJSONFile(cfg, path())
-		if err != nil {
-			slogx.Log().Debug(err.Error())
-		}
+func NewHCLFileSourceWithPath(key string, path func() string) *FileSource {
+	return defaultRegistry.NewHCLFileSourceWithPath(key, path)
+}
 
-	}
+func NewWatchedHCLFileSource(key string, path string) *FileSource {
+	return defaultRegistry.NewWatchedHCLFileSource(key, path)
+}
 
-	return newFileSourceWithOp(
-		WithKey(key),
-		WithPathFn(path),
-		withOnce(once),
-	)
+func NewDotenvFileSource(key string, path string) *FileSource {
+	return defaultRegistry.NewDotenvFileSource(key, path)
+}
 
+func NewDotenvFileSourceWithPath(key string, path func() string) *FileSource {
+	return defaultRegistry.NewDotenvFileSourceWithPath(key, path)
 }
 
-func NewYAMLFileSource(key string, path string) *FileSource {
+func NewWatchedDotenvFileSource(key string, path string) *FileSource {
+	return defaultRegistry.NewWatchedDotenvFileSource(key, path)
+}
 
-	once := func() {
+// NewFileSource
+// Creates a file source for any supported format, detected by the extension of the file.
+func NewFileSource(key string, path string) *FileSource {
+	return defaultRegistry.NewFileSource(key, path)
+}
 
-		if strutil.IsBlank(path) {
-			msg := fmt.Sprintf("filepath is empty, not able to find value for key='%s'", key)
-			slogx.Log().Debug(msg)
-			return
-		}
+func NewFileSourceWithPath(key string, path func() string) *FileSource {
+	return defaultRegistry.NewFileSourceWithPath(key, path)
+}
 
-		err := ReadFromYAMLFile(cfg, path)
-		if err != nil {
-			slogx.Log().Debug(err.Error())
-		}
+func NewWatchedFileSource(key string, path string) *FileSource {
+	return defaultRegistry.NewWatchedFileSource(key, path)
+}
 
-	}
+func (r *Registry) NewJSONFileSource(key string, path string) *FileSource {
+	return r.newFileSource(key, conv.Supplier(path), fixedParser(json.Parser()), false)
+}
 
-	return newFileSourceWithOp(
-		WithKey(key),
-		WithPath(path),
-		withOnce(once),
-	)
+func (r *Registry) NewJSONFileSourceWithPath(key string, path func() string) *FileSource {
+	return r.newFileSource(key, path, fixedParser(json.Parser()), false)
+}
 
+func (r *Registry) NewWatchedJSONFileSource(key string, path string) *FileSource {
+	return r.newFileSource(key, conv.Supplier(path), fixedParser(json.Parser()), true)
 }
 
-func NewYAMLFileSourceWithPath(key string, path func() string) *FileSource {
+func (r *Registry) NewYAMLFileSource(key string, path string) *FileSource {
+	return r.newFileSource(key, conv.Supplier(path), fixedParser(yaml.Parser()), false)
+}
+
+func (r *Registry) NewYAMLFileSourceWithPath(key string, path func() string) *FileSource {
+	return r.newFileSource(key, path, fixedParser(yaml.Parser()), false)
+}
+
+func (r *Registry) NewWatchedYAMLFileSource(key string, path string) *FileSource {
+	return r.newFileSource(key, conv.Supplier(path), fixedParser(yaml.Parser()), true)
+}
+
+func (r *Registry) NewTOMLFileSource(key string, path string) *FileSource {
+	return r.newFileSource(key, conv.Supplier(path), fixedParser(toml.Parser()), false)
+}
+
+func (r *Registry) NewTOMLFileSourceWithPath(key string, path func() string) *FileSource {
+	return r.newFileSource(key, path, fixedParser(toml.Parser()), false)
+}
+
+func (r *Registry) NewWatchedTOMLFileSource(key string, path string) *FileSource {
+	return r.newFileSource(key, conv.Supplier(path), fixedParser(toml.Parser()), true)
+}
+
+func (r *Registry) NewHCLFileSource(key string, path string) *FileSource {
+	return r.newFileSource(key, conv.Supplier(path), fixedParser(hcl.Parser(true)), false)
+}
+
+func (r *Registry) NewHCLFileSourceWithPath(key string, path func() string) *FileSource {
+	return r.newFileSource(key, path, fixedParser(hcl.Parser(true)), false)
+}
+
+func (r *Registry) NewWatchedHCLFileSource(key string, path string) *FileSource {
+	return r.newFileSource(key, conv.Supplier(path), fixedParser(hcl.Parser(true)), true)
+}
+
+func (r *Registry) NewDotenvFileSource(key string, path string) *FileSource {
+	return r.newFileSource(key, conv.Supplier(path), fixedParser(dotenv.Parser()), false)
+}
+
+func (r *Registry) NewDotenvFileSourceWithPath(key string, path func() string) *FileSource {
+	return r.newFileSource(key, path, fixedParser(dotenv.Parser()), false)
+}
+
+func (r *Registry) NewWatchedDotenvFileSource(key string, path string) *FileSource {
+	return r.newFileSource(key, conv.Supplier(path), fixedParser(dotenv.Parser()), true)
+}
+
+func (r *Registry) NewFileSource(key string, path string) *FileSource {
+	return r.newFileSource(key, conv.Supplier(path), ParserFor, false)
+}
+
+func (r *Registry) NewFileSourceWithPath(key string, path func() string) *FileSource {
+	return r.newFileSource(key, path, ParserFor, false)
+}
+
+func (r *Registry) NewWatchedFileSource(key string, path string) *FileSource {
+	return r.newFileSource(key, conv.Supplier(path), ParserFor, true)
+}
+
+func (r *Registry) newFileSource(
+	key string,
+	path func() string,
+	parserFor func(path string) (koanf.Parser, error),
+	watch bool,
+) *FileSource {
 
 	once := func() {
 
@@ -295,9 +498,23 @@ func NewYAMLFileSourceWithPath(key string, path func() string) *FileSource {
 			return
 		}
 
-		err := ReadFromYAMLFile(cfg, path())
+		parser, err := parserFor(path())
 		if err != nil {
 			slogx.Log().Debug(err.Error())
+			return
+		}
+
+		if err = r.loadShared(path(), parser); err != nil {
+			slogx.Log().Debug(err.Error())
+			return
+		}
+
+		if !watch {
+			return
+		}
+
+		if err = r.watch(path()); err != nil {
+			slogx.Log().Debug(err.Error())
 		}
 
 	}
@@ -305,13 +522,22 @@ func NewYAMLFileSourceWithPath(key string, path func() string) *FileSource {
 	return newFileSourceWithOp(
 		WithKey(key),
 		WithPathFn(path),
+		withRegistry(r),
 		withOnce(once),
 	)
 
 }
 
+func fixedParser(parser koanf.Parser) func(string) (koanf.Parser, error) {
+	return func(string) (koanf.Parser, error) {
+		return parser, nil
+	}
+}
+
 func newFileSourceWithOp(ops ...FileSourceOp) *FileSource {
-	src := &FileSource{}
+	src := &FileSource{
+		registry: defaultRegistry,
+	}
 
 	for _, op := range ops {
 		op(src)
@@ -1,6 +1,7 @@
 package cfg
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -8,6 +9,9 @@ const (
 	Dir        = "../testdata/cfg"
 	ConfigJSON = Dir + "/cfg.json"
 	ConfigYAML = Dir + "/cfg.yaml"
+	ConfigTOML = Dir + "/cfg.toml"
+	ConfigHCL  = Dir + "/cfg.hcl"
+	ConfigEnv  = Dir + "/.env"
 )
 
 func TestJSONFileSource(t *testing.T) {
@@ -22,6 +26,33 @@ func TestJSONFileSource(t *testing.T) {
 
 }
 
+func TestFileSource_LoadedOncePerPath(t *testing.T) {
+
+	// given
+	registry := NewRegistry()
+
+	first := registry.NewJSONFileSource("type", ConfigJSON)
+	overlay := registry.NewYAMLFileSource("type", ConfigYAML)
+	second := registry.NewJSONFileSource("type", ConfigJSON)
+
+	// when
+	_, _ = first.Get()
+	_, _ = overlay.Get()
+	_, _ = second.Get()
+
+	actual, _ := first.Get()
+
+	// then
+	if actual != "yaml" {
+		t.Errorf("Get() = '%v', want '%v' of the file loaded last", actual, "yaml")
+	}
+
+	if len(registry.files) != 2 {
+		t.Errorf("loaded files = %d, want %d", len(registry.files), 2)
+	}
+
+}
+
 func TestYAMLFileSource(t *testing.T) {
 
 	expected := "yaml"
@@ -33,3 +64,64 @@ func TestYAMLFileSource(t *testing.T) {
 	}
 
 }
+
+func TestFileSource_Formats(t *testing.T) {
+
+	datas := []struct {
+		name   string
+		source *FileSource
+		expect string
+	}{
+		{
+			name:   "toml",
+			source: NewRegistry().NewTOMLFileSource("type", ConfigTOML),
+			expect: "toml",
+		},
+		{
+			name:   "hcl",
+			source: NewRegistry().NewHCLFileSource("type", ConfigHCL),
+			expect: "hcl",
+		},
+		{
+			name:   "dotenv",
+			source: NewRegistry().NewDotenvFileSource("TYPE", ConfigEnv),
+			expect: "dotenv",
+		},
+		{
+			name:   "detected json",
+			source: NewRegistry().NewFileSource("type", ConfigJSON),
+			expect: "json",
+		},
+		{
+			name:   "detected toml",
+			source: NewRegistry().NewFileSource("type", ConfigTOML),
+			expect: "toml",
+		},
+		{
+			name:   "detected dotenv",
+			source: NewRegistry().NewFileSource("TYPE", ConfigEnv),
+			expect: "dotenv",
+		},
+	}
+
+	for _, data := range datas {
+		t.Run(data.name, func(t *testing.T) {
+			if actual, _ := data.source.Get(); actual != data.expect {
+				t.Errorf("Get() = '%v', want '%v'", actual, data.expect)
+			}
+		})
+	}
+
+}
+
+func TestParserFor_Unsupported(t *testing.T) {
+
+	// when
+	_, err := ParserFor("config.ini")
+
+	// then
+	if !errors.Is(err, ErrFormatUnsupported) {
+		t.Errorf("ParserFor() error = '%v', want '%v'", err, ErrFormatUnsupported)
+	}
+
+}
@@ -0,0 +1,143 @@
+package cfg
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/evgenivanovi/gpl/std"
+	slogx "github.com/evgenivanovi/gpl/stdx/log/slog"
+	"github.com/fsnotify/fsnotify"
+)
+
+// kubernetesDataDir
+// Kubernetes mounts ConfigMaps and Secrets through the '..data' symlink,
+// which is atomically replaced on every update instead of the files themselves.
+const kubernetesDataDir = "..data"
+
+// watchDebounce
+// A single save usually produces a burst of events (truncate, write, chmod),
+// reloading only after the burst settles avoids reading a half-written file.
+const watchDebounce = 100 * time.Millisecond
+
+// watcher
+// Watches directories of the configuration files rather than the files,
+// because editors and orchestrators usually replace a file instead of writing into it,
+// which silently drops a watch placed on the file itself.
+type watcher struct {
+	mu       sync.Mutex
+	fs       *fsnotify.Watcher
+	files    map[string]struct{}
+	dirs     map[string]struct{}
+	onChange func(path string)
+	done     chan struct{}
+}
+
+func newWatcher(onChange func(path string)) (*watcher, error) {
+
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &watcher{
+		fs:       fs,
+		files:    make(map[string]struct{}),
+		dirs:     make(map[string]struct{}),
+		onChange: onChange,
+		done:     make(chan struct{}),
+	}
+
+	go w.run()
+	return w, nil
+
+}
+
+func (w *watcher) add(path string) error {
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	dir := filepath.Dir(abs)
+	if _, ok := w.dirs[dir]; !ok {
+		if err = w.fs.Add(dir); err != nil {
+			return err
+		}
+		w.dirs[dir] = struct{}{}
+	}
+
+	w.files[abs] = struct{}{}
+	return nil
+
+}
+
+func (w *watcher) run() {
+
+	defer close(w.done)
+
+	pending := std.Empty
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case event, open := <-w.fs.Events:
+			{
+				if !open {
+					return
+				}
+				if w.matches(event) {
+					pending = event.Name
+					timer.Reset(watchDebounce)
+				}
+			}
+		case <-timer.C:
+			{
+				w.onChange(pending)
+			}
+		case err, open := <-w.fs.Errors:
+			{
+				if !open {
+					return
+				}
+				slogx.Log().Debug("Configuration watcher error", slogx.ErrAttr(err))
+			}
+		}
+	}
+
+}
+
+func (w *watcher) matches(event fsnotify.Event) bool {
+
+	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	name := filepath.Clean(event.Name)
+	if _, ok := w.files[name]; ok {
+		return true
+	}
+
+	if filepath.Base(name) == kubernetesDataDir {
+		_, ok := w.dirs[filepath.Dir(name)]
+		return ok
+	}
+
+	return false
+
+}
+
+func (w *watcher) close() error {
+	err := w.fs.Close()
+	<-w.done
+	return err
+}
@@ -0,0 +1,55 @@
+package cfg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/evgenivanovi/gpl/stdx"
+)
+
+func TestWatchedYAMLFileSource(t *testing.T) {
+
+	// given
+	path := filepath.Join(t.TempDir(), "watched.yaml")
+	if err := os.WriteFile(path, []byte("watched:\n  level: info\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := NewRegistry()
+	source := registry.NewWatchedYAMLFileSource("watched.level", path)
+	t.Cleanup(func() { _ = registry.StopWatching() })
+
+	if actual, _ := source.Get(); actual != "info" {
+		t.Fatalf("Get() = '%v', want '%v'", actual, "info")
+	}
+
+	changes := make(chan [2]string, 1)
+	registry.OnChange("watched.level", func(old, new stdx.Value) {
+		select {
+		case changes <- [2]string{old.String(), new.String()}:
+		default:
+		}
+	})
+
+	// when
+	if err := os.WriteFile(path, []byte("watched:\n  level: debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// then
+	select {
+	case change := <-changes:
+		if change != [2]string{"info", "debug"} {
+			t.Errorf("OnChange() = '%v', want '%v'", change, [2]string{"info", "debug"})
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnChange() handler was not called")
+	}
+
+	if actual, _ := source.Get(); actual != "debug" {
+		t.Errorf("Get() = '%v', want '%v'", actual, "debug")
+	}
+
+}
@@ -0,0 +1,212 @@
+package fw
+
+import (
+	"encoding/json"
+	"expvar"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/evgenivanovi/gpl/std"
+	zapx "github.com/evgenivanovi/gpl/stdx/log/zap"
+	"github.com/evgenivanovi/gpl/stdx/net/http/headers"
+)
+
+const ServerDefaultAdminPort = 9090
+
+const (
+	AdminPprofPath    = "/debug/pprof/"
+	AdminVarsPath     = "/debug/vars"
+	AdminRuntimePath  = "/debug/runtime"
+	AdminLogLevelPath = "/loglevel"
+	AdminInfoPath     = "/info"
+	AdminConfigPath   = "/config"
+)
+
+// WithAdmin
+// Serves the admin endpoints on a separate listener, see AdminHandler.
+func WithAdmin(enabled bool) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.adminEnabled = enabled
+	}
+}
+
+func WithAdminEnabled() ServerOp {
+	return func(settings *ServerSettings) {
+		settings.adminEnabled = true
+	}
+}
+
+func WithAdminPort(port int) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.adminPort = port
+	}
+}
+
+func WithAdminPortFn(fn func() int) ServerOp {
+	return WithAdminPort(fn())
+}
+
+// WithAdminListener
+// Serves the admin endpoints on the provided listener instead of listening on the admin address.
+func WithAdminListener(listener net.Listener) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.adminListener = listener
+	}
+}
+
+func (ss ServerSettings) AdminEnabled() bool {
+	return ss.adminEnabled
+}
+
+func (ss ServerSettings) AdminAddress() string {
+	if ss.adminListener != nil {
+		return listenerAddress(ss.adminListener)
+	}
+	if ss.adminSocket != nil {
+		return ss.adminSocket.address()
+	}
+	return net.JoinHostPort(ss.host, strconv.Itoa(ss.adminPort))
+}
+
+// WithAdminRoute
+// Adds the handler to the admin server, e.g. for metrics.
+func WithAdminRoute(pattern string, handler http.Handler) ConfigurationOp {
+	return func(cfg *Configuration) {
+		cfg.AdminRoutes[pattern] = handler
+	}
+}
+
+// BuildInfo
+// Describes the running binary.
+type BuildInfo struct {
+	Name      string `json:"name,omitempty"`
+	Version   string `json:"version,omitempty"`
+	GoVersion string `json:"go"`
+	Module    string `json:"module,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+// RuntimeStats
+// Describes the state of the Go runtime.
+type RuntimeStats struct {
+	Uptime       string `json:"uptime"`
+	Goroutines   int    `json:"goroutines"`
+	GOMAXPROCS   int    `json:"gomaxprocs"`
+	CPUs         int    `json:"cpus"`
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	TotalAlloc   uint64 `json:"total_alloc"`
+	Sys          uint64 `json:"sys"`
+	NumGC        uint32 `json:"num_gc"`
+	PauseTotalNs uint64 `json:"pause_total_ns"`
+}
+
+// AdminHandler
+// Serves the operational endpoints of the application:
+//
+//	/debug/pprof/   profiles of net/http/pprof
+//	/debug/vars     variables of expvar
+//	/debug/runtime  RuntimeStats
+//	/loglevel       the level of stdx/log/zap, changed by PUT {"level":"info"}
+//	/info           BuildInfo of Application.Meta and the binary
+//	/config         the snapshot of Application.Config with redacted secrets
+//	/healthz        the liveness report
+//	/readyz         the readiness report
+//
+// The handler must not be exposed publicly.
+func AdminHandler(app *Application, routes map[string]http.Handler) http.Handler {
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(AdminPprofPath, pprof.Index)
+	mux.HandleFunc(AdminPprofPath+"cmdline", pprof.Cmdline)
+	mux.HandleFunc(AdminPprofPath+"profile", pprof.Profile)
+	mux.HandleFunc(AdminPprofPath+"symbol", pprof.Symbol)
+	mux.HandleFunc(AdminPprofPath+"trace", pprof.Trace)
+
+	mux.Handle(AdminVarsPath, expvar.Handler())
+	mux.Handle(AdminLogLevelPath, zapx.AtomicLevel())
+	mux.Handle(AdminConfigPath, app.Config.Handler())
+	mux.Handle(HealthzPath, app.Health.LiveHandler())
+	mux.Handle(ReadyzPath, app.Health.ReadyHandler())
+
+	mux.HandleFunc(AdminInfoPath, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, buildInfo(app))
+	})
+
+	mux.HandleFunc(AdminRuntimePath, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, runtimeStats(app))
+	})
+
+	for pattern, handler := range routes {
+		mux.Handle(pattern, handler)
+	}
+
+	return mux
+
+}
+
+func buildInfo(app *Application) BuildInfo {
+
+	info := BuildInfo{
+		Name:      app.Meta.Name,
+		Version:   app.Meta.Version,
+		GoVersion: runtime.Version(),
+	}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.Module = build.Main.Path
+	if info.Version == std.Empty {
+		info.Version = build.Main.Version
+	}
+
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.Time = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+
+	return info
+
+}
+
+func runtimeStats(app *Application) RuntimeStats {
+
+	memory := runtime.MemStats{}
+	runtime.ReadMemStats(&memory)
+
+	return RuntimeStats{
+		Uptime:       time.Since(app.created).Round(time.Second).String(),
+		Goroutines:   runtime.NumGoroutine(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		CPUs:         runtime.NumCPU(),
+		HeapAlloc:    memory.HeapAlloc,
+		HeapInuse:    memory.HeapInuse,
+		TotalAlloc:   memory.TotalAlloc,
+		Sys:          memory.Sys,
+		NumGC:        memory.NumGC,
+		PauseTotalNs: memory.PauseTotalNs,
+	}
+
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set(headers.ContentTypeKey.String(), headers.TypeApplicationJSON.String())
+	_ = json.NewEncoder(w).Encode(value)
+}
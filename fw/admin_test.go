@@ -0,0 +1,115 @@
+package fw
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/evgenivanovi/gpl/meta"
+	zapx "github.com/evgenivanovi/gpl/stdx/log/zap"
+	"go.uber.org/zap"
+)
+
+func TestAdminHandler_Info(t *testing.T) {
+
+	// given
+	cfg := NewConfiguration(WithMeta(meta.NewApp("orders", "1.2.3")))
+	cfg.apply()
+	handler := AdminHandler(cfg.App, cfg.AdminRoutes)
+
+	// when
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, AdminInfoPath, nil))
+
+	// then
+	info := BuildInfo{}
+	if err := json.NewDecoder(recorder.Body).Decode(&info); err != nil {
+		t.Fatalf("decode info: %v", err)
+	}
+
+	if info.Name != "orders" || info.Version != "1.2.3" || info.GoVersion == "" {
+		t.Errorf("info = %+v, want orders 1.2.3 with the go version", info)
+	}
+
+}
+
+func TestAdminHandler_LogLevel(t *testing.T) {
+
+	// given
+	cfg := NewConfiguration()
+	handler := AdminHandler(cfg.App, cfg.AdminRoutes)
+
+	previous := zapx.Level()
+	t.Cleanup(func() { zapx.AtomicLevel().SetLevel(previous) })
+
+	// when
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(
+		recorder,
+		httptest.NewRequest(http.MethodPut, AdminLogLevelPath, strings.NewReader(`{"level":"warn"}`)),
+	)
+
+	// then
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("PUT %s = %d, want %d", AdminLogLevelPath, recorder.Code, http.StatusOK)
+	}
+
+	if zapx.Level() != zap.WarnLevel {
+		t.Errorf("Level() = '%s', want '%s'", zapx.Level(), zap.WarnLevel)
+	}
+
+}
+
+func TestAdminHandler_Routes(t *testing.T) {
+
+	// given
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	cfg := NewConfiguration(WithAdminRoute("/metrics", metrics))
+	handler := AdminHandler(cfg.App, cfg.AdminRoutes)
+
+	paths := map[string]int{
+		"/metrics":                 http.StatusTeapot,
+		AdminRuntimePath:           http.StatusOK,
+		AdminVarsPath:              http.StatusOK,
+		AdminConfigPath:            http.StatusOK,
+		AdminPprofPath:             http.StatusOK,
+		HealthzPath:                http.StatusOK,
+		ReadyzPath:                 http.StatusServiceUnavailable,
+		AdminPprofPath + "cmdline": http.StatusOK,
+	}
+
+	for path, code := range paths {
+
+		// when
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+		// then
+		if recorder.Code != code {
+			t.Errorf("GET %s = %d, want %d", path, recorder.Code, code)
+		}
+
+	}
+
+}
+
+func TestServerSettings_Admin(t *testing.T) {
+
+	// given
+	settings := NewServerSettings(WithHost("localhost"), WithAdminEnabled(), WithAdminPort(9191))
+
+	// then
+	if !settings.AdminEnabled() {
+		t.Errorf("AdminEnabled() = false, want true")
+	}
+
+	if settings.AdminAddress() != "localhost:9191" {
+		t.Errorf("AdminAddress() = '%s', want '%s'", settings.AdminAddress(), "localhost:9191")
+	}
+
+}
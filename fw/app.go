@@ -1,37 +1,100 @@
 package fw
 
 import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/evgenivanovi/gpl/cfg"
+	"github.com/evgenivanovi/gpl/meta"
+	slogx "github.com/evgenivanovi/gpl/stdx/log/slog"
 	"github.com/evgenivanovi/gpl/stdx/xsync"
 )
 
 type Application struct {
 	Settings ServerSettings
 	Context  map[string]any
+	Config   *cfg.Registry
+	Health   *Health
+	Meta     meta.App
 
 	onStartTasks           []func()
 	onStartBackgroundTasks []func()
 
 	onCloseTasks           []func()
 	onCloseBackgroundTasks []func()
+
+	servicesMu sync.Mutex
+	services   map[serviceKey]*service
+	closers    []io.Closer
+
+	lifecycle  sync.Mutex
+	components []*component
+	started    []*component
+	workers    []*worker
+
+	created     time.Time
+	startupOnce sync.Once
+	startupDone chan struct{}
+
+	restartable atomic.Bool
+	restarts    chan chan error
+
+	// shutdownMu orders the shutdown request against marking the application ready.
+	shutdownMu        sync.Mutex
+	shutdownOnce      sync.Once
+	shutdownRequested chan struct{}
+	shutdownDoneOnce  sync.Once
+	shutdownDone      chan struct{}
+	shutdownErr       error
 }
 
 func NewApplication() *Application {
 	return &Application{
 		Context: make(map[string]any),
+		Config:  cfg.Default(),
+		Health:  NewHealth(),
+
+		services: make(map[serviceKey]*service),
 
 		onStartTasks:           make([]func(), 0),
 		onStartBackgroundTasks: make([]func(), 0),
 
 		onCloseTasks:           make([]func(), 0),
 		onCloseBackgroundTasks: make([]func(), 0),
+
+		components: make([]*component, 0),
+		started:    make([]*component, 0),
+
+		created:     time.Now(),
+		startupDone: make(chan struct{}),
+
+		restarts: make(chan chan error),
+
+		shutdownRequested: make(chan struct{}),
+		shutdownDone:      make(chan struct{}),
 	}
 }
 
+// Put
+// Stores the value under the key.
+//
+// Deprecated: use Provide or ProvideValue for typed services.
 func (a *Application) Put(key string, value any) {
+	a.servicesMu.Lock()
+	defer a.servicesMu.Unlock()
 	a.Context[key] = value
 }
 
+// Get
+// Returns the value stored under the key.
+//
+// Deprecated: use Resolve for typed services.
 func (a *Application) Get(key string) any {
+	a.servicesMu.Lock()
+	defer a.servicesMu.Unlock()
 	return a.Context[key]
 }
 
@@ -39,6 +102,10 @@ func (a *Application) RegisterOnStart(task func()) {
 	a.onStartTasks = append(a.onStartTasks, task)
 }
 
+// RegisterOnStartBackground
+// Runs the task in parallel with the other background tasks, the start waits until every task returns.
+//
+// Deprecated: use RegisterWorker for tasks running for the lifetime of the application.
 func (a *Application) RegisterOnStartBackground(task func()) {
 	a.onStartBackgroundTasks = append(a.onStartBackgroundTasks, task)
 }
@@ -56,7 +123,16 @@ func (a *Application) RegisterOnCloseBackground(task func()) {
 	a.onCloseBackgroundTasks = append(a.onCloseBackgroundTasks, task)
 }
 
+// Close
+// Runs the tasks registered by RegisterOnClose and closes the constructed services.
 func (a *Application) Close() {
+	a.runCloseTasks()
+	if err := a.closeServices(); err != nil {
+		slogx.Log().Error(fmt.Sprintf("Could not close services (error: %s)", err))
+	}
+}
+
+func (a *Application) runCloseTasks() {
 	xsync.ExecuteParallel(a.onCloseBackgroundTasks...)
 	xsync.ExecuteSequential(a.onCloseTasks...)
 }
@@ -0,0 +1,148 @@
+package fw
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	slogx "github.com/evgenivanovi/gpl/stdx/log/slog"
+)
+
+// DefaultCertCheckInterval
+// Limits how often the certificate files are checked for changes.
+const DefaultCertCheckInterval = time.Second
+
+var ErrCertificate = errors.New("could not load certificate")
+
+type CertManagerOp func(*CertManager)
+
+// WithCertCheckInterval
+// Checks the certificate files for changes at most once per interval, DefaultCertCheckInterval by default.
+// A non-positive interval checks them on every handshake.
+func WithCertCheckInterval(interval time.Duration) CertManagerOp {
+	return func(manager *CertManager) {
+		manager.interval = interval
+	}
+}
+
+// CertManager
+// Serves the certificate of the cert and key files and reloads it when the files change,
+// so that a renewed certificate is used without restarting the servers.
+// A certificate that fails to load is reported and the previous one is kept.
+type CertManager struct {
+	cert     string
+	key      string
+	interval time.Duration
+
+	mu          sync.RWMutex
+	certificate *tls.Certificate
+	certMod     time.Time
+	keyMod      time.Time
+	checked     time.Time
+}
+
+// NewCertManager
+// Loads the certificate of the cert and key files.
+func NewCertManager(cert, key string, ops ...CertManagerOp) (*CertManager, error) {
+
+	manager := &CertManager{
+		cert:     cert,
+		key:      key,
+		interval: DefaultCertCheckInterval,
+	}
+
+	for _, op := range ops {
+		op(manager)
+	}
+
+	if err := manager.Reload(); err != nil {
+		return nil, err
+	}
+
+	return manager, nil
+
+}
+
+// Certificate
+// Returns the current certificate.
+func (m *CertManager) Certificate() *tls.Certificate {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.certificate
+}
+
+// GetCertificate
+// Returns the current certificate, reloading it when the files have changed, see tls.Config.GetCertificate.
+func (m *CertManager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+
+	if m.due() {
+		if err := m.reload(false); err != nil {
+			slogx.Log().Warn(
+				"Could not reload certificate, the previous one is kept",
+				slog.String("cert", m.cert),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	return m.Certificate(), nil
+
+}
+
+// Reload
+// Loads the certificate of the files, even when they have not changed.
+func (m *CertManager) Reload() error {
+	return m.reload(true)
+}
+
+func (m *CertManager) due() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return time.Since(m.checked) >= m.interval
+}
+
+func (m *CertManager) reload(force bool) error {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.checked = time.Now()
+
+	certMod, err := modified(m.cert)
+	if err != nil {
+		return err
+	}
+
+	keyMod, err := modified(m.key)
+	if err != nil {
+		return err
+	}
+
+	if !force && certMod.Equal(m.certMod) && keyMod.Equal(m.keyMod) {
+		return nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(m.cert, m.key)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCertificate, err)
+	}
+
+	m.certificate = &certificate
+	m.certMod = certMod
+	m.keyMod = keyMod
+
+	return nil
+
+}
+
+func modified(file string) (time.Time, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %w", ErrCertificate, err)
+	}
+	return info.ModTime(), nil
+}
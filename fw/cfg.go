@@ -3,6 +3,8 @@ package fw
 import (
 	"net/http"
 
+	"github.com/evgenivanovi/gpl/cfg"
+	"github.com/evgenivanovi/gpl/meta"
 	grpcfw "github.com/evgenivanovi/gpl/server"
 	"google.golang.org/grpc"
 )
@@ -15,6 +17,33 @@ func WithApplication(app *Application) ConfigurationOp {
 	}
 }
 
+// WithConfigRegistry
+// Attaches the configuration registry to the application instead of the default one,
+// when RunServer runs the configuration.
+func WithConfigRegistry(registry *cfg.Registry) ConfigurationOp {
+	return func(cfg *Configuration) {
+		cfg.ConfigRegistry = registry
+	}
+}
+
+// WithHealthEndpoints
+// Serves the health of the application at HealthzPath and ReadyzPath
+// and as the grpc.health.v1 service, disabled by default not to shadow the routes
+// and the health service of the application.
+func WithHealthEndpoints(enabled bool) ConfigurationOp {
+	return func(cfg *Configuration) {
+		cfg.HealthEndpoints = enabled
+	}
+}
+
+// WithMeta
+// Describes the application, e.g. on the admin server, when RunServer runs the configuration.
+func WithMeta(meta meta.App) ConfigurationOp {
+	return func(cfg *Configuration) {
+		cfg.Meta = &meta
+	}
+}
+
 func WithHTTPHandler(handler http.Handler) ConfigurationOp {
 	return func(cfg *Configuration) {
 		cfg.HTTPHandler = handler
@@ -39,15 +68,38 @@ func WithGRPCStreamMW(mws ...grpc.StreamServerInterceptor) ConfigurationOp {
 	}
 }
 
+// WithGRPCServerOps
+// Adds options to the gRPC server, e.g. message limits, keepalive and stats handlers.
+// They are applied after the options of the configuration and so override them.
+func WithGRPCServerOps(ops ...grpcfw.GRPCServerOp) ConfigurationOp {
+	return func(cfg *Configuration) {
+		cfg.GRPCServerOps = append(cfg.GRPCServerOps, ops...)
+	}
+}
+
 type Configuration struct {
 	App *Application
 
+	// ConfigRegistry and Meta replace those of App when it is run,
+	// so that they do not depend on the order of the options.
+	ConfigRegistry *cfg.Registry
+	Meta           *meta.App
+
 	HTTPHandler http.Handler
 
+	HealthEndpoints bool
+
+	AdminRoutes map[string]http.Handler
+
+	Shutdown ShutdownSettings
+
+	Restart RestartSettings
+
 	GRPCReflection bool
 	GRPCServices   []grpcfw.GRPCService
 	GRPCUnaryMWs   []grpc.UnaryServerInterceptor
 	GRPCStreamMWs  []grpc.StreamServerInterceptor
+	GRPCServerOps  []grpcfw.GRPCServerOp
 }
 
 func NewConfiguration(ops ...ConfigurationOp) *Configuration {
@@ -61,10 +113,28 @@ func NewConfiguration(ops ...ConfigurationOp) *Configuration {
 func defaultConfiguration() *Configuration {
 	return &Configuration{
 		App:           NewApplication(),
+		Shutdown:      defaultShutdownSettings(),
+		Restart:       defaultRestartSettings(),
+		AdminRoutes:   make(map[string]http.Handler),
 		GRPCServices:  make([]grpcfw.GRPCService, 0),
 		GRPCUnaryMWs:  make([]grpc.UnaryServerInterceptor, 0),
 		GRPCStreamMWs: make([]grpc.StreamServerInterceptor, 0),
+		GRPCServerOps: make([]grpcfw.GRPCServerOp, 0),
+	}
+}
+
+// apply
+// Attaches the registry and the description of the configuration to the application.
+func (c *Configuration) apply() {
+
+	if c.ConfigRegistry != nil {
+		c.App.Config = c.ConfigRegistry
+	}
+
+	if c.Meta != nil {
+		c.App.Meta = *c.Meta
 	}
+
 }
 
 func (c *Configuration) WithHTTPHandler(handler http.Handler) *Configuration {
@@ -101,3 +171,10 @@ func (c *Configuration) WithGRPCStreamMW(mws ...grpc.StreamServerInterceptor) *C
 	}
 	return c
 }
+
+func (c *Configuration) WithGRPCServerOps(ops ...grpcfw.GRPCServerOp) *Configuration {
+	if c != nil && len(ops) != 0 {
+		c.GRPCServerOps = append(c.GRPCServerOps, ops...)
+	}
+	return c
+}
@@ -0,0 +1,40 @@
+package fw
+
+import (
+	"testing"
+
+	"github.com/evgenivanovi/gpl/cfg"
+	"github.com/evgenivanovi/gpl/meta"
+)
+
+func TestConfiguration_ApplyAfterApplication(t *testing.T) {
+
+	// given
+	registry := cfg.NewRegistry()
+	app := NewApplication()
+	shared := app.Config
+
+	configuration := NewConfiguration(
+		WithConfigRegistry(registry),
+		WithMeta(meta.NewApp("orders", "1.2.3")),
+		WithApplication(app),
+	)
+
+	// then
+	if app.Config != shared {
+		t.Error("options changed the application before it is run")
+	}
+
+	// when
+	configuration.apply()
+
+	// then
+	if app.Config != registry {
+		t.Error("Config is not the registry of the configuration")
+	}
+
+	if app.Meta.Name != "orders" {
+		t.Errorf("Meta.Name = '%s', want '%s'", app.Meta.Name, "orders")
+	}
+
+}
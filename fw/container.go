@@ -0,0 +1,266 @@
+package fw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/evgenivanovi/gpl/std"
+	me "github.com/hashicorp/go-multierror"
+)
+
+var ErrServiceDuplicate = errors.New("service is provided more than once")
+var ErrServiceNotProvided = errors.New("service is not provided")
+var ErrServiceCycle = errors.New("services depend on each other")
+var ErrNoApplication = errors.New("context carries no application")
+
+const constructingKey contextKey = "ctx.app.constructing"
+
+type ServiceOp func(*serviceKey)
+
+// Named
+// Distinguishes services of the same type.
+func Named(name string) ServiceOp {
+	return func(key *serviceKey) {
+		key.name = name
+	}
+}
+
+type serviceKey struct {
+	typ  reflect.Type
+	name string
+}
+
+func (k serviceKey) String() string {
+	if k.name == std.Empty {
+		return k.typ.String()
+	}
+	return k.typ.String() + "(" + k.name + ")"
+}
+
+type service struct {
+	key       serviceKey
+	construct func(ctx context.Context) (any, error)
+	value     any
+	built     bool
+	// building is closed when the construction in flight ends.
+	building chan struct{}
+	// waiting is the service the construction in flight is resolving.
+	waiting *service
+}
+
+func keyOf[T any](ops []ServiceOp) serviceKey {
+	key := serviceKey{typ: reflect.TypeFor[T]()}
+	for _, op := range ops {
+		op(&key)
+	}
+	return key
+}
+
+// Provide
+// Registers the constructor of the service of type T, it is called once, on the first Resolve.
+// The context of the constructor carries the application, so that dependencies are resolved with it.
+// A service implementing io.Closer is closed when the application is closed,
+// in the reverse order of construction.
+func Provide[T any](app *Application, constructor func(ctx context.Context) (T, error), ops ...ServiceOp) error {
+	return app.provide(keyOf[T](ops), &service{
+		construct: func(ctx context.Context) (any, error) {
+			return constructor(ctx)
+		},
+	})
+}
+
+// ProvideValue
+// Registers the constructed service of type T.
+// It is closed with the application as well when it implements io.Closer.
+func ProvideValue[T any](app *Application, value T, ops ...ServiceOp) error {
+	return Provide(app, func(context.Context) (T, error) { return value, nil }, ops...)
+}
+
+// Resolve
+// Returns the service of type T of the application carried by the context, see WithCtx,
+// constructing it and its dependencies on the first call.
+func Resolve[T any](ctx context.Context, ops ...ServiceOp) (T, error) {
+
+	var zero T
+
+	app := FromCtx(ctx)
+	if app == nil {
+		return zero, ErrNoApplication
+	}
+
+	value, err := app.resolve(ctx, keyOf[T](ops))
+	if err != nil {
+		return zero, err
+	}
+
+	// A service of an interface type may be nil.
+	typed, _ := value.(T)
+	return typed, nil
+
+}
+
+// MustResolve
+// Returns the service of type T like Resolve, it panics on failure.
+func MustResolve[T any](ctx context.Context, ops ...ServiceOp) T {
+	return std.Must(Resolve[T](ctx, ops...))
+}
+
+func (a *Application) provide(key serviceKey, s *service) error {
+
+	a.servicesMu.Lock()
+	defer a.servicesMu.Unlock()
+
+	if _, ok := a.services[key]; ok {
+		return fmt.Errorf("%w: %s", ErrServiceDuplicate, key)
+	}
+
+	s.key = key
+	a.services[key] = s
+	return nil
+
+}
+
+// resolve
+// Every service is constructed once: a resolution of a service in flight waits for its construction,
+// unless the constructions in flight wait for each other, which is reported as a cycle.
+// The context of a constructor carries the service it constructs, so that what it resolves is recorded
+// as the service its construction waits for.
+func (a *Application) resolve(ctx context.Context, key serviceKey) (any, error) {
+
+	s, err := a.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+
+	constructing, _ := ctx.Value(constructingKey).(*service)
+
+	a.servicesMu.Lock()
+
+	for !s.built && s.building != nil {
+
+		if cycle := a.cycle(constructing, s); cycle != nil {
+			a.servicesMu.Unlock()
+			return nil, fmt.Errorf("%w: %s", ErrServiceCycle, path(cycle))
+		}
+
+		building := s.building
+		a.wait(constructing, s)
+		a.servicesMu.Unlock()
+
+		<-building
+
+		a.servicesMu.Lock()
+		a.wait(constructing, nil)
+
+	}
+
+	if s.built {
+		value := s.value
+		a.servicesMu.Unlock()
+		return value, nil
+	}
+
+	s.building = make(chan struct{})
+	a.wait(constructing, s)
+	a.servicesMu.Unlock()
+
+	value, err := s.construct(context.WithValue(WithCtx(ctx, a), constructingKey, s))
+
+	a.servicesMu.Lock()
+	defer a.servicesMu.Unlock()
+
+	a.wait(constructing, nil)
+	close(s.building)
+	s.building = nil
+
+	// A failed construction is not kept, the next resolution constructs the service again.
+	if err != nil {
+		return nil, fmt.Errorf("construct service '%s': %w", key, err)
+	}
+
+	s.value = value
+	s.built = true
+
+	if closer, ok := value.(io.Closer); ok {
+		a.closers = append(a.closers, closer)
+	}
+
+	return value, nil
+
+}
+
+// wait
+// Records the service the construction waits for, it is called under servicesMu.
+func (a *Application) wait(constructing *service, target *service) {
+	if constructing != nil {
+		constructing.waiting = target
+	}
+}
+
+// cycle
+// Follows the services the constructions in flight wait for, starting at the target,
+// and returns the path when it leads back to the construction resolving the target.
+// It is called under servicesMu.
+func (a *Application) cycle(constructing *service, target *service) []serviceKey {
+
+	if constructing == nil {
+		return nil
+	}
+
+	keys := make([]serviceKey, 0)
+	for current := target; current != nil; current = current.waiting {
+		keys = append(keys, current.key)
+		if current == constructing {
+			return append(keys, target.key)
+		}
+	}
+
+	return nil
+
+}
+
+func (a *Application) lookup(key serviceKey) (*service, error) {
+
+	a.servicesMu.Lock()
+	defer a.servicesMu.Unlock()
+
+	s, ok := a.services[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrServiceNotProvided, key)
+	}
+
+	return s, nil
+
+}
+
+// closeServices
+// Closes the constructed services in reverse order, every service is closed even if some fail.
+func (a *Application) closeServices() error {
+
+	a.servicesMu.Lock()
+	closers := a.closers
+	a.closers = nil
+	a.servicesMu.Unlock()
+
+	errs := newErrors()
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i].Close(); err != nil {
+			errs = me.Append(errs, fmt.Errorf("close service '%T': %w", closers[i], err))
+		}
+	}
+
+	return errs.ErrorOrNil()
+
+}
+
+func path(keys []serviceKey) string {
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		names = append(names, key.String())
+	}
+	return strings.Join(names, " -> ")
+}
@@ -0,0 +1,251 @@
+package fw
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type repository struct {
+	name   string
+	closed *[]string
+}
+
+func (r *repository) Close() error {
+	*r.closed = append(*r.closed, r.name)
+	return nil
+}
+
+type usecase struct {
+	repository *repository
+}
+
+func TestResolve_Singleton(t *testing.T) {
+
+	// given
+	app := NewApplication()
+	ctx := WithCtx(context.Background(), app)
+
+	var calls atomic.Int32
+	_ = Provide(app, func(ctx context.Context) (*repository, error) {
+		calls.Add(1)
+		return &repository{name: "orders"}, nil
+	})
+	_ = Provide(app, func(ctx context.Context) (*usecase, error) {
+		repository, err := Resolve[*repository](ctx)
+		return &usecase{repository: repository}, err
+	})
+
+	// when
+	results := make([]*usecase, 8)
+	wg := sync.WaitGroup{}
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = MustResolve[*usecase](ctx)
+		}()
+	}
+	wg.Wait()
+
+	repository := MustResolve[*repository](ctx)
+
+	// then
+	if calls.Load() != 1 {
+		t.Errorf("constructor called %d times, want once", calls.Load())
+	}
+
+	for _, result := range results {
+		if result != results[0] || result.repository != repository {
+			t.Fatalf("Resolve() returned different instances")
+		}
+	}
+
+}
+
+func TestResolve_FreshContext(t *testing.T) {
+
+	// given
+	app := NewApplication()
+	ctx := WithCtx(context.Background(), app)
+
+	_ = ProvideValue(app, &repository{name: "orders"})
+	_ = Provide(app, func(context.Context) (*usecase, error) {
+		repository, err := Resolve[*repository](WithCtx(context.Background(), app))
+		return &usecase{repository: repository}, err
+	})
+
+	// when
+	result := make(chan error, 1)
+	go func() {
+		_, err := Resolve[*usecase](ctx)
+		result <- err
+	}()
+
+	// then
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Errorf("Resolve() unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Resolve() through a fresh context did not return")
+	}
+
+}
+
+func TestResolve_LaterFromConstructorContext(t *testing.T) {
+
+	// given
+	app := NewApplication()
+	ctx := WithCtx(context.Background(), app)
+
+	var calls atomic.Int32
+	_ = Provide(app, func(context.Context) (*repository, error) {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return &repository{name: "orders", closed: new([]string)}, nil
+	})
+
+	captured := make(chan context.Context, 1)
+	_ = Provide(app, func(ctx context.Context) (*usecase, error) {
+		captured <- ctx
+		return &usecase{}, nil
+	})
+
+	_ = MustResolve[*usecase](ctx)
+	later := <-captured
+
+	// when
+	wg := sync.WaitGroup{}
+	for _, current := range []context.Context{ctx, later, ctx, later} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = MustResolve[*repository](current)
+		}()
+	}
+	wg.Wait()
+
+	// then
+	if calls.Load() != 1 {
+		t.Errorf("constructor called %d times, want once", calls.Load())
+	}
+
+	if len(app.closers) != 1 {
+		t.Errorf("closers = %d, want the service registered once", len(app.closers))
+	}
+
+}
+
+func TestResolve_NilInterface(t *testing.T) {
+
+	// given
+	app := NewApplication()
+	ctx := WithCtx(context.Background(), app)
+
+	_ = Provide(app, func(context.Context) (io.Reader, error) {
+		return nil, nil
+	})
+	_ = ProvideValue[io.Writer](app, nil)
+
+	// when
+	reader, readerErr := Resolve[io.Reader](ctx)
+	writer, writerErr := Resolve[io.Writer](ctx)
+
+	// then
+	if reader != nil || readerErr != nil {
+		t.Errorf("Resolve() = %v, %v, want nil, nil", reader, readerErr)
+	}
+
+	if writer != nil || writerErr != nil {
+		t.Errorf("Resolve() = %v, %v, want nil, nil", writer, writerErr)
+	}
+
+}
+
+func TestResolve_Errors(t *testing.T) {
+
+	// given
+	app := NewApplication()
+	ctx := WithCtx(context.Background(), app)
+
+	_ = Provide(app, func(ctx context.Context) (*usecase, error) {
+		_, err := Resolve[*repository](ctx)
+		return nil, err
+	})
+	_ = Provide(app, func(ctx context.Context) (*repository, error) {
+		_, err := Resolve[*usecase](ctx)
+		return nil, err
+	})
+
+	// when
+	_, cycle := Resolve[*usecase](ctx)
+	_, missing := Resolve[string](ctx)
+	_, orphan := Resolve[*usecase](context.Background())
+	duplicate := ProvideValue(app, &usecase{})
+
+	// then
+	if !errors.Is(cycle, ErrServiceCycle) {
+		t.Errorf("Resolve() error = %v, want %v", cycle, ErrServiceCycle)
+	}
+
+	if !strings.Contains(cycle.Error(), "*fw.usecase -> *fw.repository -> *fw.usecase") {
+		t.Errorf("Resolve() error = %v, does not describe the cycle", cycle)
+	}
+
+	if !errors.Is(missing, ErrServiceNotProvided) {
+		t.Errorf("Resolve() error = %v, want %v", missing, ErrServiceNotProvided)
+	}
+
+	if !errors.Is(orphan, ErrNoApplication) {
+		t.Errorf("Resolve() error = %v, want %v", orphan, ErrNoApplication)
+	}
+
+	if !errors.Is(duplicate, ErrServiceDuplicate) {
+		t.Errorf("ProvideValue() error = %v, want %v", duplicate, ErrServiceDuplicate)
+	}
+
+}
+
+func TestApplication_CloseServices(t *testing.T) {
+
+	// given
+	closed := make([]string, 0)
+
+	app := NewApplication()
+	ctx := WithCtx(context.Background(), app)
+
+	_ = ProvideValue(app, &repository{name: "primary", closed: &closed}, Named("primary"))
+	_ = Provide(
+		app,
+		func(ctx context.Context) (*repository, error) {
+			MustResolve[*repository](ctx, Named("primary"))
+			return &repository{name: "replica", closed: &closed}, nil
+		},
+		Named("replica"),
+	)
+	_ = ProvideValue(app, &repository{name: "unused", closed: &closed}, Named("unused"))
+
+	MustResolve[*repository](ctx, Named("replica"))
+
+	// when
+	err := app.CloseContext(context.Background())
+	app.Close()
+
+	// then
+	if err != nil {
+		t.Fatalf("CloseContext() unexpected error: %v", err)
+	}
+
+	if want := []string{"replica", "primary"}; !reflect.DeepEqual(closed, want) {
+		t.Errorf("closed = %v, want %v", closed, want)
+	}
+
+}
@@ -0,0 +1,288 @@
+// Package fwtest runs a fw.Configuration in-process for tests:
+// the servers listen on ephemeral ports or in memory and are stopped when the test ends.
+package fwtest
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evgenivanovi/gpl/fw"
+	netx "github.com/evgenivanovi/gpl/stdx/net"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	DefaultStartTimeout = 10 * time.Second
+	DefaultStopTimeout  = 10 * time.Second
+	BufconnSize         = 1 << 20
+)
+
+const loopback = "127.0.0.1:0"
+
+type Op func(*options)
+
+// WithBufconn
+// Serves gRPC in memory instead of on a TCP port, GRPCAddress is empty in this mode.
+// It has no effect in the single port mode.
+func WithBufconn() Op {
+	return func(o *options) {
+		o.bufconn = true
+	}
+}
+
+// WithStartTimeout
+// Limits the time the configuration may take to become ready, DefaultStartTimeout by default.
+func WithStartTimeout(timeout time.Duration) Op {
+	return func(o *options) {
+		o.startTimeout = timeout
+	}
+}
+
+// WithStopTimeout
+// Limits the time the configuration may take to shut down, DefaultStopTimeout by default.
+func WithStopTimeout(timeout time.Duration) Op {
+	return func(o *options) {
+		o.stopTimeout = timeout
+	}
+}
+
+// WithGRPCDialOptions
+// Adds options to the gRPC client connection, e.g. interceptors.
+func WithGRPCDialOptions(dialOptions ...grpc.DialOption) Op {
+	return func(o *options) {
+		o.dialOptions = append(o.dialOptions, dialOptions...)
+	}
+}
+
+type options struct {
+	bufconn      bool
+	startTimeout time.Duration
+	stopTimeout  time.Duration
+	dialOptions  []grpc.DialOption
+}
+
+// Server
+// Configuration running in-process with the clients connected to it.
+type Server struct {
+	// App is the application of the configuration.
+	App *fw.Application
+
+	// HTTPAddress, HTTPSAddress, GRPCAddress and AdminAddress are the actual addresses of the enabled servers.
+	HTTPAddress  string
+	HTTPSAddress string
+	GRPCAddress  string
+	AdminAddress string
+
+	// HTTPClient sends requests to the HTTP server, and to the HTTPS server
+	// without verifying its certificate.
+	HTTPClient *http.Client
+	// GRPCConn is connected to the gRPC server.
+	GRPCConn *grpc.ClientConn
+
+	t       testing.TB
+	options *options
+	result  chan error
+	once    sync.Once
+}
+
+// Start
+// Runs the configuration on ephemeral loopback ports, without handling OS signals,
+// and waits until it is ready. The server is stopped by Close, which is registered with t.Cleanup.
+func Start(t testing.TB, cfg *fw.Configuration, ops ...Op) *Server {
+
+	t.Helper()
+
+	o := &options{
+		startTimeout: DefaultStartTimeout,
+		stopTimeout:  DefaultStopTimeout,
+	}
+
+	for _, op := range ops {
+		op(o)
+	}
+
+	settings := &cfg.App.Settings
+	var buffer *bufconn.Listener
+
+	if settings.HttpEnabled() {
+		fw.WithHttpListener(listen(t))(settings)
+	}
+
+	if settings.HttpsEnabled() {
+		fw.WithHttpsListener(listen(t))(settings)
+	}
+
+	if settings.AdminEnabled() {
+		fw.WithAdminListener(listen(t))(settings)
+	}
+
+	// In the single port mode gRPC is served on the HTTP or HTTPS listener.
+	if settings.GrpcEnabled() && !settings.Multiplexed() {
+		if o.bufconn {
+			buffer = bufconn.Listen(BufconnSize)
+			fw.WithGrpcListener(buffer)(settings)
+		} else {
+			fw.WithGrpcListener(listen(t))(settings)
+		}
+	}
+
+	fw.WithShutdownSignals()(cfg)
+
+	server := &Server{
+		App:     cfg.App,
+		t:       t,
+		options: o,
+		result:  make(chan error, 1),
+		HTTPClient: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: insecureTLS(),
+			},
+		},
+	}
+
+	if settings.HttpEnabled() {
+		server.HTTPAddress = settings.HttpAddress()
+	}
+
+	if settings.HttpsEnabled() {
+		server.HTTPSAddress = settings.HttpsAddress()
+	}
+
+	if settings.AdminEnabled() {
+		server.AdminAddress = settings.AdminAddress()
+	}
+
+	if settings.GrpcEnabled() && (!o.bufconn || settings.Multiplexed()) {
+		server.GRPCAddress = settings.GrpcAddress()
+	}
+
+	go func() {
+		server.result <- fw.RunServer(cfg)
+	}()
+
+	t.Cleanup(server.Close)
+
+	select {
+	case <-cfg.App.Started():
+	case err := <-server.result:
+		// RunServer has already stopped everything it started.
+		server.once.Do(func() {})
+		t.Fatalf("fwtest: configuration stopped while starting: %v", err)
+	case <-time.After(o.startTimeout):
+		t.Fatalf("fwtest: configuration did not become ready in %s", o.startTimeout)
+	}
+
+	if settings.GrpcEnabled() {
+		// gRPC is served under TLS when it shares the port with HTTPS only or is secured itself.
+		secure := settings.Multiplexed() && !settings.HttpEnabled() || settings.GrpcTLSEnabled()
+		server.GRPCConn = server.dial(buffer, secure)
+	}
+
+	return server
+
+}
+
+// URL
+// Returns the URL of the path on the HTTP server.
+func (s *Server) URL(path string) string {
+	return "http://" + s.HTTPAddress + path
+}
+
+// SecureURL
+// Returns the URL of the path on the HTTPS server.
+func (s *Server) SecureURL(path string) string {
+	return "https://" + s.HTTPSAddress + path
+}
+
+// AdminURL
+// Returns the URL of the path on the admin server.
+func (s *Server) AdminURL(path string) string {
+	return "http://" + s.AdminAddress + path
+}
+
+// Close
+// Closes the clients and shuts the configuration down, failing the test if the shutdown fails.
+// It is safe to call Close more than once.
+func (s *Server) Close() {
+	s.once.Do(func() {
+
+		if s.GRPCConn != nil {
+			_ = s.GRPCConn.Close()
+		}
+
+		s.HTTPClient.CloseIdleConnections()
+
+		ctx, cancel := context.WithTimeout(context.Background(), s.options.stopTimeout)
+		defer cancel()
+
+		if err := s.App.Shutdown(ctx); err != nil {
+			s.t.Errorf("fwtest: shutdown: %v", err)
+			return
+		}
+
+		if err := <-s.result; err != nil {
+			s.t.Errorf("fwtest: run server: %v", err)
+		}
+
+	})
+}
+
+func (s *Server) dial(buffer *bufconn.Listener, secure bool) *grpc.ClientConn {
+
+	s.t.Helper()
+
+	target := s.GRPCAddress
+	dialOptions := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+
+	if secure {
+		dialOptions = []grpc.DialOption{
+			grpc.WithTransportCredentials(credentials.NewTLS(insecureTLS())),
+		}
+	}
+
+	if buffer != nil {
+		target = "passthrough:///bufconn"
+		dialOptions = append(dialOptions, grpc.WithContextDialer(
+			func(ctx context.Context, _ string) (net.Conn, error) {
+				return buffer.DialContext(ctx)
+			},
+		))
+	}
+
+	conn, err := grpc.NewClient(target, append(dialOptions, s.options.dialOptions...)...)
+	if err != nil {
+		s.t.Fatalf("fwtest: dial grpc server: %v", err)
+	}
+
+	return conn
+
+}
+
+// insecureTLS
+// Skips the verification of the certificate, test servers commonly use self-signed ones.
+func insecureTLS() *tls.Config {
+	return &tls.Config{InsecureSkipVerify: true}
+}
+
+func listen(t testing.TB) net.Listener {
+
+	t.Helper()
+
+	listener, err := net.Listen(netx.TCP, loopback)
+	if err != nil {
+		t.Fatalf("fwtest: listen: %v", err)
+	}
+
+	return listener
+
+}
@@ -0,0 +1,194 @@
+package fwtest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/evgenivanovi/gpl/fw"
+	grpcfw "github.com/evgenivanovi/gpl/server"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+func configuration() *fw.Configuration {
+
+	app := fw.NewApplication()
+	app.Settings = *fw.NewServerSettings(fw.WithHttpEnabled(), fw.WithGrpcEnabled())
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	return fw.NewConfiguration(fw.WithApplication(app), fw.WithHTTPHandler(handler), fw.WithHealthEndpoints(true))
+
+}
+
+func TestStart(t *testing.T) {
+
+	tests := []struct {
+		name string
+		ops  []Op
+	}{
+		{name: "tcp"},
+		{name: "bufconn", ops: []Op{WithBufconn()}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			// given
+			server := Start(t, configuration(), test.ops...)
+
+			// when
+			response, err := server.HTTPClient.Get(server.URL("/api"))
+			if err != nil {
+				t.Fatalf("GET /api unexpected error: %v", err)
+			}
+			_ = response.Body.Close()
+
+			ready, err := server.HTTPClient.Get(server.URL(fw.ReadyzPath))
+			if err != nil {
+				t.Fatalf("GET %s unexpected error: %v", fw.ReadyzPath, err)
+			}
+			_ = ready.Body.Close()
+
+			health, err := grpc_health_v1.NewHealthClient(server.GRPCConn).
+				Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+			// then
+			if response.StatusCode != http.StatusAccepted {
+				t.Errorf("GET /api = %d, want %d", response.StatusCode, http.StatusAccepted)
+			}
+
+			if ready.StatusCode != http.StatusOK {
+				t.Errorf("GET %s = %d, want %d", fw.ReadyzPath, ready.StatusCode, http.StatusOK)
+			}
+
+			if err != nil || health.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+				t.Errorf("Check() = %v, %v, want SERVING", health, err)
+			}
+
+			if test.name == "tcp" && server.GRPCAddress == "" {
+				t.Error("GRPCAddress is empty for the tcp mode")
+			}
+
+		})
+	}
+
+}
+
+func TestStart_Multiplexing(t *testing.T) {
+
+	// given
+	cfg := configuration()
+	fw.WithMultiplexingEnabled()(&cfg.App.Settings)
+
+	server := Start(t, cfg)
+
+	// when
+	response, err := server.HTTPClient.Get(server.URL("/api"))
+	if err != nil {
+		t.Fatalf("GET /api unexpected error: %v", err)
+	}
+	_ = response.Body.Close()
+
+	health, err := grpc_health_v1.NewHealthClient(server.GRPCConn).
+		Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+	// then
+	if server.GRPCAddress != server.HTTPAddress {
+		t.Errorf("GRPCAddress = '%s', want the HTTP address '%s'", server.GRPCAddress, server.HTTPAddress)
+	}
+
+	if response.StatusCode != http.StatusAccepted {
+		t.Errorf("GET /api = %d, want %d", response.StatusCode, http.StatusAccepted)
+	}
+
+	if err != nil || health.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Errorf("Check() = %v, %v, want SERVING", health, err)
+	}
+
+}
+
+func TestStart_Admin(t *testing.T) {
+
+	// given
+	cfg := configuration()
+	fw.WithAdminEnabled()(&cfg.App.Settings)
+
+	server := Start(t, cfg)
+
+	// when
+	response, err := server.HTTPClient.Get(server.AdminURL(fw.AdminRuntimePath))
+	if err != nil {
+		t.Fatalf("GET %s unexpected error: %v", fw.AdminRuntimePath, err)
+	}
+	_ = response.Body.Close()
+
+	// then
+	if server.AdminAddress == "" || server.AdminAddress == server.HTTPAddress {
+		t.Errorf("AdminAddress = '%s', want a separate address", server.AdminAddress)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("GET %s = %d, want %d", fw.AdminRuntimePath, response.StatusCode, http.StatusOK)
+	}
+
+}
+
+type counter struct {
+	rpcs atomic.Int32
+}
+
+func (c *counter) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	c.rpcs.Add(1)
+	return ctx
+}
+
+func (c *counter) HandleRPC(context.Context, stats.RPCStats) {}
+
+func (c *counter) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (c *counter) HandleConn(context.Context, stats.ConnStats) {}
+
+func TestStart_GRPCServerOps(t *testing.T) {
+
+	// given
+	handler := &counter{}
+
+	cfg := configuration().WithGRPCServerOps(
+		grpcfw.WithMaxRecvMsgSize(1024),
+		grpcfw.WithStatsHandlers(handler),
+	)
+
+	server := Start(t, cfg)
+	client := grpc_health_v1.NewHealthClient(server.GRPCConn)
+
+	// when
+	_, small := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	_, large := client.Check(
+		context.Background(),
+		&grpc_health_v1.HealthCheckRequest{Service: strings.Repeat("s", 2048)},
+	)
+
+	// then
+	if small != nil {
+		t.Errorf("Check() unexpected error: %v", small)
+	}
+
+	if status.Code(large) != codes.ResourceExhausted {
+		t.Errorf("Check() of a large message = %v, want %s", large, codes.ResourceExhausted)
+	}
+
+	if handler.rpcs.Load() == 0 {
+		t.Error("stats handler has not seen any RPC")
+	}
+
+}
@@ -0,0 +1,319 @@
+package fw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	grpcfw "github.com/evgenivanovi/gpl/server"
+	"github.com/evgenivanovi/gpl/stdx/net/http/headers"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	HealthzPath = "/healthz"
+	ReadyzPath  = "/readyz"
+)
+
+const DefaultCheckTimeout = 5 * time.Second
+
+var ErrCheckTimeout = errors.New("health check did not complete in time")
+var ErrNotReady = errors.New("application is not ready")
+
+type HealthStatus string
+
+const (
+	StatusUp   HealthStatus = "up"
+	StatusDown HealthStatus = "down"
+)
+
+// Check
+// Reports the problem with a dependency or a part of the application, nil means healthy.
+type Check func(ctx context.Context) error
+
+type CheckOp func(*healthCheck)
+
+// WithCheckTimeout
+// Limits the time the check may take, DefaultCheckTimeout by default.
+func WithCheckTimeout(timeout time.Duration) CheckOp {
+	return func(c *healthCheck) {
+		c.timeout = timeout
+	}
+}
+
+// NonCritical
+// Reports the failure of the check without making the application not ready, e.g. for an optional cache.
+func NonCritical() CheckOp {
+	return func(c *healthCheck) {
+		c.critical = false
+	}
+}
+
+// Liveness
+// Includes the check into liveness as well, e.g. for a deadlock detector.
+// A failed liveness check makes the orchestrator restart the application,
+// so checks of external dependencies do not belong there.
+func Liveness() CheckOp {
+	return func(c *healthCheck) {
+		c.liveness = true
+	}
+}
+
+type healthCheck struct {
+	name     string
+	check    Check
+	timeout  time.Duration
+	critical bool
+	liveness bool
+}
+
+// CheckResult
+// Describes the outcome of a single check.
+type CheckResult struct {
+	Name     string        `json:"name"`
+	Status   HealthStatus  `json:"status"`
+	Critical bool          `json:"critical"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// HealthReport
+// Describes the outcome of all checks, the status is down when any critical check fails.
+type HealthReport struct {
+	Status HealthStatus  `json:"status"`
+	Error  string        `json:"error,omitempty"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Health
+// Registry of the health checks of the application.
+// The application is live while its liveness checks pass and
+// ready while it is not shutting down and its critical checks pass.
+type Health struct {
+	mu     sync.RWMutex
+	checks []*healthCheck
+	ready  atomic.Bool
+}
+
+func NewHealth() *Health {
+	return &Health{
+		checks: make([]*healthCheck, 0),
+	}
+}
+
+// Register
+// Adds the check, a check with the same name is replaced.
+func (h *Health) Register(name string, check Check, ops ...CheckOp) {
+
+	registered := &healthCheck{
+		name:     name,
+		check:    check,
+		timeout:  DefaultCheckTimeout,
+		critical: true,
+	}
+
+	for _, op := range ops {
+		op(registered)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for index, existing := range h.checks {
+		if existing.name == name {
+			h.checks[index] = registered
+			return
+		}
+	}
+
+	h.checks = append(h.checks, registered)
+
+}
+
+// SetReady
+// Marks the application as ready to accept traffic or not, e.g. at the start of a graceful shutdown.
+func (h *Health) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+// Live
+// Runs the liveness checks.
+func (h *Health) Live(ctx context.Context) HealthReport {
+	return h.report(ctx, true, func(c *healthCheck) bool { return c.liveness })
+}
+
+// Ready
+// Runs all checks, the report is down while the application is not marked ready.
+func (h *Health) Ready(ctx context.Context) HealthReport {
+	return h.report(ctx, h.ready.Load(), func(*healthCheck) bool { return true })
+}
+
+// CheckOne
+// Runs the check with the provided name.
+func (h *Health) CheckOne(ctx context.Context, name string) (CheckResult, bool) {
+
+	h.mu.RLock()
+	var found *healthCheck
+	for _, c := range h.checks {
+		if c.name == name {
+			found = c
+		}
+	}
+	h.mu.RUnlock()
+
+	if found == nil {
+		return CheckResult{}, false
+	}
+
+	return found.run(ctx), true
+
+}
+
+func (h *Health) report(ctx context.Context, ready bool, include func(*healthCheck) bool) HealthReport {
+
+	h.mu.RLock()
+	selected := make([]*healthCheck, 0, len(h.checks))
+	for _, c := range h.checks {
+		if include(c) {
+			selected = append(selected, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	results := make([]CheckResult, len(selected))
+
+	wg := sync.WaitGroup{}
+	for index, c := range selected {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[index] = c.run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := HealthReport{
+		Status: StatusUp,
+		Checks: results,
+	}
+
+	if !ready {
+		report.Status = StatusDown
+		report.Error = ErrNotReady.Error()
+	}
+
+	for _, result := range results {
+		if result.Critical && result.Status == StatusDown {
+			report.Status = StatusDown
+		}
+	}
+
+	return report
+
+}
+
+func (c *healthCheck) run(ctx context.Context) CheckResult {
+
+	started := time.Now()
+	err := run(ctx, c.timeout, c.check, ErrCheckTimeout)
+
+	result := CheckResult{
+		Name:     c.name,
+		Status:   StatusUp,
+		Critical: c.critical,
+		Duration: time.Since(started),
+	}
+
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+
+}
+
+// LiveHandler
+// Serves the liveness report as JSON with 200 when live and 503 otherwise.
+func (h *Health) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, h.Live(r.Context()))
+	})
+}
+
+// ReadyHandler
+// Serves the readiness report as JSON with 200 when ready and 503 otherwise.
+func (h *Health) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, h.Ready(r.Context()))
+	})
+}
+
+// Handler
+// Serves HealthzPath and ReadyzPath and delegates every other request to the provided handler,
+// http.DefaultServeMux when it is nil, as http.Server does.
+func (h *Health) Handler(next http.Handler) http.Handler {
+
+	if next == nil {
+		next = http.DefaultServeMux
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(HealthzPath, h.LiveHandler())
+	mux.Handle(ReadyzPath, h.ReadyHandler())
+	mux.Handle("/", next)
+
+	return mux
+
+}
+
+// GRPCService
+// Serves the readiness as the standard grpc.health.v1 service,
+// the empty service name stands for the whole application, other names for the named checks.
+func (h *Health) GRPCService() grpcfw.GRPCService {
+	return grpcfw.NewHealthService(h.servingStatus)
+}
+
+func (h *Health) servingStatus(ctx context.Context, service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, bool) {
+
+	if service == "" {
+		return servingStatus(h.Ready(ctx).Status), true
+	}
+
+	result, ok := h.CheckOne(ctx, service)
+	if !ok {
+		return grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN, false
+	}
+
+	if !h.ready.Load() {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING, true
+	}
+
+	return servingStatus(result.Status), true
+
+}
+
+func servingStatus(status HealthStatus) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	if status == StatusUp {
+		return grpc_health_v1.HealthCheckResponse_SERVING
+	}
+	return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+}
+
+func writeReport(w http.ResponseWriter, report HealthReport) {
+
+	w.Header().Set(headers.ContentTypeKey.String(), headers.TypeApplicationJSON.String())
+
+	if report.Status == StatusUp {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	_ = json.NewEncoder(w).Encode(report)
+
+}
@@ -0,0 +1,145 @@
+package fw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func healthy(context.Context) error {
+	return nil
+}
+
+func TestHealth_Ready(t *testing.T) {
+
+	// given
+	health := NewHealth()
+	health.Register("db", healthy)
+	health.Register("cache", func(context.Context) error { return errors.New("unreachable") }, NonCritical())
+
+	// when
+	before := health.Ready(context.Background())
+	health.SetReady(true)
+	after := health.Ready(context.Background())
+
+	// then
+	if before.Status != StatusDown {
+		t.Errorf("Ready() = '%s' before SetReady, want '%s'", before.Status, StatusDown)
+	}
+
+	if after.Status != StatusUp || len(after.Checks) != 2 {
+		t.Errorf("Ready() = %+v, want up with both checks", after)
+	}
+
+}
+
+func TestHealth_CriticalFailure(t *testing.T) {
+
+	// given
+	health := NewHealth()
+	health.SetReady(true)
+
+	health.Register("db", func(context.Context) error { return errors.New("connection refused") })
+	health.Register(
+		"broker",
+		func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+		WithCheckTimeout(10*time.Millisecond),
+	)
+
+	// when
+	report := health.Ready(context.Background())
+
+	// then
+	if report.Status != StatusDown {
+		t.Fatalf("Ready() = '%s', want '%s'", report.Status, StatusDown)
+	}
+
+	for _, check := range report.Checks {
+		if check.Status != StatusDown {
+			t.Errorf("Ready() check '%s' = '%s', want '%s'", check.Name, check.Status, StatusDown)
+		}
+	}
+
+}
+
+func TestHealth_Handler(t *testing.T) {
+
+	// given
+	health := NewHealth()
+	health.Register("db", func(context.Context) error { return errors.New("connection refused") })
+	health.Register("loop", healthy, Liveness())
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	handler := health.Handler(next)
+	health.SetReady(true)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	// when
+	live := serve(HealthzPath)
+	ready := serve(ReadyzPath)
+	other := serve("/api")
+
+	// then
+	if live.Code != http.StatusOK {
+		t.Errorf("%s = %d, want %d", HealthzPath, live.Code, http.StatusOK)
+	}
+
+	report := HealthReport{}
+	if err := json.NewDecoder(live.Body).Decode(&report); err != nil || len(report.Checks) != 1 {
+		t.Errorf("%s = %+v, %v, want only the liveness check", HealthzPath, report, err)
+	}
+
+	if ready.Code != http.StatusServiceUnavailable {
+		t.Errorf("%s = %d, want %d", ReadyzPath, ready.Code, http.StatusServiceUnavailable)
+	}
+
+	if other.Code != http.StatusTeapot {
+		t.Errorf("/api = %d, want %d", other.Code, http.StatusTeapot)
+	}
+
+}
+
+func TestHealth_ServingStatus(t *testing.T) {
+
+	// given
+	health := NewHealth()
+	health.Register("db", healthy)
+	health.SetReady(true)
+
+	// when & then
+	if status, ok := health.servingStatus(context.Background(), ""); !ok || status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Errorf("servingStatus('') = %s, %v, want SERVING", status, ok)
+	}
+
+	if status, ok := health.servingStatus(context.Background(), "db"); !ok || status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Errorf("servingStatus('db') = %s, %v, want SERVING", status, ok)
+	}
+
+	if _, ok := health.servingStatus(context.Background(), "unknown"); ok {
+		t.Errorf("servingStatus('unknown') reported a known service")
+	}
+
+	health.SetReady(false)
+
+	if status, _ := health.servingStatus(context.Background(), ""); status != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("servingStatus('') = %s after shutdown began, want NOT_SERVING", status)
+	}
+
+}
@@ -0,0 +1,173 @@
+package fw
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	slogx "github.com/evgenivanovi/gpl/stdx/log/slog"
+)
+
+// The read and write timeouts are disabled by default, so that long downloads, server-sent events
+// and gRPC streams in the single port mode are not cut off. The headers are still read in limited time
+// and so is the body of every request but gRPC ones, so that a slow client does not hold a connection forever.
+const (
+	DefaultReadHeaderTimeout = 5 * time.Second
+	DefaultReadTimeout       = time.Duration(0)
+	DefaultBodyReadTimeout   = 60 * time.Second
+	DefaultWriteTimeout      = time.Duration(0)
+	DefaultIdleTimeout       = 120 * time.Second
+	DefaultMaxHeaderBytes    = 64 << 10
+)
+
+// HTTPSettings
+// Timeouts and limits of the HTTP and HTTPS servers, a zero timeout disables the limit.
+// In the single port mode the read and write timeouts limit every gRPC stream as well.
+type HTTPSettings struct {
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	BodyReadTimeout   time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+
+	// ConnState is called when a connection changes its state, e.g. to count open connections.
+	ConnState func(net.Conn, http.ConnState)
+
+	// Logger is carried by the context of every request and logs the errors of the servers.
+	Logger *slog.Logger
+}
+
+// WithReadHeaderTimeout
+// Limits the time to read the request headers, DefaultReadHeaderTimeout by default.
+func WithReadHeaderTimeout(timeout time.Duration) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.http.ReadHeaderTimeout = timeout
+	}
+}
+
+// WithReadTimeout
+// Limits the time to read the whole request, unlimited by default,
+// so that the bodies of gRPC streams in the single port mode are not cut off, see WithBodyReadTimeout.
+func WithReadTimeout(timeout time.Duration) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.http.ReadTimeout = timeout
+	}
+}
+
+// WithBodyReadTimeout
+// Limits the time to read the request body of every request but gRPC ones, DefaultBodyReadTimeout by default.
+// The limit starts when the handler is called, reading the body past it fails.
+func WithBodyReadTimeout(timeout time.Duration) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.http.BodyReadTimeout = timeout
+	}
+}
+
+// WithWriteTimeout
+// Limits the time to write the response, unlimited by default.
+func WithWriteTimeout(timeout time.Duration) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.http.WriteTimeout = timeout
+	}
+}
+
+// WithIdleTimeout
+// Limits the time a keep-alive connection waits for the next request, DefaultIdleTimeout by default.
+func WithIdleTimeout(timeout time.Duration) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.http.IdleTimeout = timeout
+	}
+}
+
+// WithMaxHeaderBytes
+// Limits the size of the request headers, DefaultMaxHeaderBytes by default.
+func WithMaxHeaderBytes(size int) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.http.MaxHeaderBytes = size
+	}
+}
+
+// WithConnState
+// Observes the state changes of the connections of every HTTP server, see http.Server.ConnState.
+func WithConnState(hook func(net.Conn, http.ConnState)) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.http.ConnState = hook
+	}
+}
+
+// WithHttpLogger
+// Sets the logger carried by the request contexts, the default logger of stdx/log/slog by default.
+func WithHttpLogger(logger *slog.Logger) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.http.Logger = logger
+	}
+}
+
+func (ss ServerSettings) HttpSettings() HTTPSettings {
+	return ss.http
+}
+
+func defaultHTTPSettings() HTTPSettings {
+	return HTTPSettings{
+		ReadHeaderTimeout: DefaultReadHeaderTimeout,
+		ReadTimeout:       DefaultReadTimeout,
+		BodyReadTimeout:   DefaultBodyReadTimeout,
+		WriteTimeout:      DefaultWriteTimeout,
+		IdleTimeout:       DefaultIdleTimeout,
+		MaxHeaderBytes:    DefaultMaxHeaderBytes,
+	}
+}
+
+// newHTTPServer
+// Creates the server with the timeouts and limits of the settings,
+// the context of every request carries the application and the logger, see FromCtx.
+func newHTTPServer(app *Application, address string, handler http.Handler) *http.Server {
+
+	settings := app.Settings.http
+
+	logger := settings.Logger
+	if logger == nil {
+		logger = slogx.Log()
+	}
+
+	return &http.Server{
+		Addr:              address,
+		Handler:           withBodyReadTimeout(handler, settings.BodyReadTimeout),
+		ReadHeaderTimeout: settings.ReadHeaderTimeout,
+		ReadTimeout:       settings.ReadTimeout,
+		WriteTimeout:      settings.WriteTimeout,
+		IdleTimeout:       settings.IdleTimeout,
+		MaxHeaderBytes:    settings.MaxHeaderBytes,
+		ConnState:         settings.ConnState,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+		BaseContext: func(net.Listener) context.Context {
+			return slogx.WithCtx(WithCtx(context.Background(), app), logger)
+		},
+	}
+
+}
+
+// withBodyReadTimeout
+// Sets the read deadline of every request but gRPC ones, a non-positive timeout leaves the handler as it is.
+func withBodyReadTimeout(handler http.Handler, timeout time.Duration) http.Handler {
+
+	if timeout <= 0 {
+		return handler
+	}
+
+	if handler == nil {
+		handler = http.DefaultServeMux
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isGRPC(r) {
+			// Writers not supporting deadlines, e.g. of tests, are served without the limit.
+			_ = http.NewResponseController(w).SetReadDeadline(time.Now().Add(timeout))
+		}
+		handler.ServeHTTP(w, r)
+	})
+
+}
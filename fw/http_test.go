@@ -0,0 +1,167 @@
+package fw
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	slogx "github.com/evgenivanovi/gpl/stdx/log/slog"
+)
+
+func TestRunServer_HttpSettings(t *testing.T) {
+
+	// given
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var connections atomic.Int32
+	var carried atomic.Bool
+
+	app := NewApplication()
+	app.Settings = *NewServerSettings(
+		WithHttpEnabled(),
+		WithHttpListener(listener),
+		WithReadHeaderTimeout(50*time.Millisecond),
+		WithHttpLogger(logger),
+		WithConnState(func(conn net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				connections.Add(1)
+			}
+		}),
+	)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		carried.Store(FromCtx(r.Context()) == app && slogx.FromCtx(r.Context()) == logger)
+	})
+
+	cfg := NewConfiguration(WithApplication(app), WithHTTPHandler(handler), WithShutdownSignals())
+
+	result := make(chan error, 1)
+	go func() {
+		result <- RunServer(cfg)
+	}()
+
+	waitReady(t, app)
+
+	t.Cleanup(func() {
+		_ = app.Shutdown(context.Background())
+		<-result
+	})
+
+	// when
+	response, err := http.Get("http://" + app.Settings.HttpAddress() + "/api")
+	if err != nil {
+		t.Fatalf("GET /api unexpected error: %v", err)
+	}
+	_ = response.Body.Close()
+
+	// A client sending the headers slowly is disconnected once the timeout is hit.
+	slow, err := net.Dial("tcp", app.Settings.HttpAddress())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer slow.Close()
+
+	_, _ = slow.Write([]byte("GET /api HTTP/1.1\r\nHost: localhost\r\n"))
+	_ = slow.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, readErr := io.ReadAll(slow)
+
+	// then
+	if !carried.Load() {
+		t.Error("request context carries neither the application nor the logger")
+	}
+
+	if connections.Load() < 2 {
+		t.Errorf("ConnState observed %d new connections, want 2", connections.Load())
+	}
+
+	if readErr != nil {
+		t.Errorf("slow client was not disconnected: %v", readErr)
+	}
+
+}
+
+func TestNewServerSettings_HttpDefaults(t *testing.T) {
+
+	// when
+	settings := NewServerSettings().HttpSettings()
+
+	// then
+	if settings.ReadHeaderTimeout != DefaultReadHeaderTimeout || settings.MaxHeaderBytes != DefaultMaxHeaderBytes {
+		t.Errorf("HttpSettings() = %+v, want the secure defaults", settings)
+	}
+
+	if settings.ReadTimeout != 0 || settings.WriteTimeout != 0 {
+		t.Errorf("HttpSettings() = %+v, want unlimited read and write", settings)
+	}
+
+	if settings.BodyReadTimeout != DefaultBodyReadTimeout {
+		t.Errorf("HttpSettings() = %+v, want the body read in limited time", settings)
+	}
+
+}
+
+func TestRunServer_BodyReadTimeout(t *testing.T) {
+
+	// given
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	app := NewApplication()
+	app.Settings = *NewServerSettings(
+		WithHttpEnabled(),
+		WithHttpListener(listener),
+		WithBodyReadTimeout(50*time.Millisecond),
+	)
+
+	read := make(chan error, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.ReadAll(r.Body)
+		read <- err
+	})
+
+	cfg := NewConfiguration(WithApplication(app), WithHTTPHandler(handler), WithShutdownSignals())
+
+	result := make(chan error, 1)
+	go func() {
+		result <- RunServer(cfg)
+	}()
+
+	waitReady(t, app)
+
+	t.Cleanup(func() {
+		_ = app.Shutdown(context.Background())
+		<-result
+	})
+
+	// when
+	slow, err := net.Dial("tcp", app.Settings.HttpAddress())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer slow.Close()
+
+	_, _ = slow.Write([]byte("POST /api HTTP/1.1\r\nHost: localhost\r\nContent-Length: 10\r\n\r\nslow"))
+
+	// then
+	select {
+	case err := <-read:
+		if err == nil {
+			t.Error("reading the body of a slow client succeeded, want the deadline hit")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reading the body of a slow client was not limited")
+	}
+
+}
@@ -0,0 +1,290 @@
+package fw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/evgenivanovi/gpl/std"
+	slogx "github.com/evgenivanovi/gpl/stdx/log/slog"
+	"github.com/evgenivanovi/gpl/stdx/mex"
+	"github.com/evgenivanovi/gpl/stdx/xsync"
+	me "github.com/hashicorp/go-multierror"
+)
+
+var ErrComponentDuplicate = errors.New("component is registered more than once")
+var ErrComponentUnknown = errors.New("component depends on unknown component")
+var ErrComponentCycle = errors.New("components depend on each other")
+var ErrComponentTimeout = errors.New("component did not complete in time")
+
+// Component
+// Part of the application that is started before the servers and stopped after them.
+type Component interface {
+	Name() string
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// NewComponent
+// Creates the component from functions, any of which may be nil.
+func NewComponent(name string, start, stop func(ctx context.Context) error) Component {
+	return &funcComponent{
+		name:  name,
+		start: start,
+		stop:  stop,
+	}
+}
+
+type funcComponent struct {
+	name  string
+	start func(ctx context.Context) error
+	stop  func(ctx context.Context) error
+}
+
+func (c *funcComponent) Name() string {
+	return c.name
+}
+
+func (c *funcComponent) Start(ctx context.Context) error {
+	if c.start == nil {
+		return nil
+	}
+	return c.start(ctx)
+}
+
+func (c *funcComponent) Stop(ctx context.Context) error {
+	if c.stop == nil {
+		return nil
+	}
+	return c.stop(ctx)
+}
+
+type ComponentOp func(*component)
+
+// DependsOn
+// Starts the component after the named components and stops it before them.
+func DependsOn(names ...string) ComponentOp {
+	return func(c *component) {
+		c.dependsOn = append(c.dependsOn, names...)
+	}
+}
+
+// WithStartTimeout
+// Limits the time the component may take to start.
+func WithStartTimeout(timeout time.Duration) ComponentOp {
+	return func(c *component) {
+		c.startTimeout = timeout
+	}
+}
+
+// WithStopTimeout
+// Limits the time the component may take to stop.
+func WithStopTimeout(timeout time.Duration) ComponentOp {
+	return func(c *component) {
+		c.stopTimeout = timeout
+	}
+}
+
+type component struct {
+	Component
+	dependsOn    []string
+	startTimeout time.Duration
+	stopTimeout  time.Duration
+}
+
+// RegisterComponent
+// Registers the component started by StartContext and stopped by CloseContext.
+// The order is checked when the application is started.
+func (a *Application) RegisterComponent(c Component, ops ...ComponentOp) {
+
+	registered := &component{
+		Component: c,
+	}
+
+	for _, op := range ops {
+		op(registered)
+	}
+
+	a.lifecycle.Lock()
+	defer a.lifecycle.Unlock()
+	a.components = append(a.components, registered)
+
+}
+
+// StartContext
+// Runs the tasks registered by RegisterOnStart and then starts the components
+// in the order of their dependencies, each within its start timeout.
+// When any component fails the components already started are stopped in reverse order
+// and the single returned error lists every failure.
+func (a *Application) StartContext(ctx context.Context) error {
+
+	a.Start()
+
+	a.lifecycle.Lock()
+	defer a.lifecycle.Unlock()
+
+	ordered, err := order(a.components)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range ordered {
+
+		slogx.Log().Debug("Starting component", slog.String("component", c.Name()))
+
+		if err := run(ctx, c.startTimeout, c.Start, ErrComponentTimeout); err != nil {
+			errs := newErrors()
+			errs = me.Append(errs, fmt.Errorf("start component '%s': %w", c.Name(), err))
+			errs = me.Append(errs, a.stopStarted(ctx))
+			return errs.ErrorOrNil()
+		}
+
+		a.started = append(a.started, c)
+
+	}
+
+	return nil
+
+}
+
+// CloseContext
+// Stops the started components in reverse order, each within its stop timeout,
+// then runs the tasks registered by RegisterOnClose and closes the constructed services.
+// Every component is stopped even if some fail, the single returned error lists every failure.
+func (a *Application) CloseContext(ctx context.Context) error {
+
+	a.lifecycle.Lock()
+	err := a.stopStarted(ctx)
+	a.lifecycle.Unlock()
+
+	a.runCloseTasks()
+
+	errs := newErrors()
+	errs = me.Append(errs, err, a.closeServices())
+	return errs.ErrorOrNil()
+
+}
+
+func (a *Application) stopStarted(ctx context.Context) error {
+
+	errs := newErrors()
+
+	for index := len(a.started) - 1; index >= 0; index-- {
+
+		c := a.started[index]
+		slogx.Log().Debug("Stopping component", slog.String("component", c.Name()))
+
+		if err := run(ctx, c.stopTimeout, c.Stop, ErrComponentTimeout); err != nil {
+			errs = me.Append(errs, fmt.Errorf("stop component '%s': %w", c.Name(), err))
+		}
+
+	}
+
+	a.started = a.started[:0]
+	return errs.ErrorOrNil()
+
+}
+
+// run
+// Executes the hook within the timeout, a hook ignoring the cancellation of its context
+// is abandoned with timeoutErr when the timeout expires. Panics are returned as xsync.ErrRecovered.
+func run(ctx context.Context, timeout time.Duration, hook func(ctx context.Context) error, timeoutErr error) error {
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	done := xsync.GoErrorFunc(func() error {
+		return hook(ctx)
+	})
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%w: %s: %w", timeoutErr, timeout, ctx.Err())
+		}
+		return ctx.Err()
+	}
+
+}
+
+// order
+// Sorts the components topologically, keeping the registration order among independent ones.
+func order(components []*component) ([]*component, error) {
+
+	byName := make(map[string]*component, len(components))
+	errs := newErrors()
+
+	for _, c := range components {
+		if _, ok := byName[c.Name()]; ok {
+			errs = me.Append(errs, fmt.Errorf("%w: '%s'", ErrComponentDuplicate, c.Name()))
+			continue
+		}
+		byName[c.Name()] = c
+	}
+
+	for _, c := range components {
+		for _, dependency := range c.dependsOn {
+			if _, ok := byName[dependency]; !ok {
+				errs = me.Append(errs, fmt.Errorf("%w: '%s' on '%s'", ErrComponentUnknown, c.Name(), dependency))
+			}
+		}
+	}
+
+	if err := errs.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	marks := make(map[string]int, len(components))
+	ordered := make([]*component, 0, len(components))
+
+	var visit func(c *component, path []string) error
+	visit = func(c *component, path []string) error {
+
+		switch marks[c.Name()] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("%w: %v", ErrComponentCycle, append(path, c.Name()))
+		}
+
+		marks[c.Name()] = visiting
+		for _, dependency := range c.dependsOn {
+			if err := visit(byName[dependency], append(path, c.Name())); err != nil {
+				return err
+			}
+		}
+		marks[c.Name()] = visited
+
+		ordered = append(ordered, c)
+		return nil
+
+	}
+
+	for _, c := range components {
+		if err := visit(c, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+
+}
+
+func newErrors() *me.Error {
+	return &me.Error{
+		ErrorFormat: mex.AppendFormat(std.SemicolonSpace),
+	}
+}
@@ -0,0 +1,198 @@
+package fw
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evgenivanovi/gpl/stdx/xsync"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) component(name string, startErr error) Component {
+	return NewComponent(
+		name,
+		func(ctx context.Context) error {
+			r.record("start " + name)
+			return startErr
+		},
+		func(ctx context.Context) error {
+			r.record("stop " + name)
+			return nil
+		},
+	)
+}
+
+func (r *recorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func TestApplication_Lifecycle(t *testing.T) {
+
+	// given
+	events := &recorder{}
+	app := NewApplication()
+
+	app.RegisterComponent(events.component("http", nil), DependsOn("cache", "db"))
+	app.RegisterComponent(events.component("cache", nil), DependsOn("db"))
+	app.RegisterComponent(events.component("db", nil))
+
+	// when
+	startErr := app.StartContext(context.Background())
+	closeErr := app.CloseContext(context.Background())
+
+	// then
+	if startErr != nil || closeErr != nil {
+		t.Fatalf("StartContext() = %v, CloseContext() = %v, want nil", startErr, closeErr)
+	}
+
+	expected := []string{
+		"start db", "start cache", "start http",
+		"stop http", "stop cache", "stop db",
+	}
+
+	if !reflect.DeepEqual(events.events, expected) {
+		t.Errorf("events = %v, want %v", events.events, expected)
+	}
+
+}
+
+func TestApplication_StartFailure(t *testing.T) {
+
+	// given
+	failure := errors.New("connection refused")
+
+	events := &recorder{}
+	app := NewApplication()
+
+	app.RegisterComponent(events.component("db", nil))
+	app.RegisterComponent(events.component("broker", failure), DependsOn("db"))
+	app.RegisterComponent(events.component("http", nil), DependsOn("broker"))
+
+	// when
+	err := app.StartContext(context.Background())
+
+	// then
+	if !errors.Is(err, failure) {
+		t.Fatalf("StartContext() error = '%v', want '%v'", err, failure)
+	}
+
+	expected := []string{"start db", "start broker", "stop db"}
+	if !reflect.DeepEqual(events.events, expected) {
+		t.Errorf("events = %v, want %v", events.events, expected)
+	}
+
+}
+
+func TestApplication_StartTimeout(t *testing.T) {
+
+	// given
+	app := NewApplication()
+
+	app.RegisterComponent(
+		NewComponent(
+			"slow",
+			func(ctx context.Context) error {
+				time.Sleep(time.Second)
+				return nil
+			},
+			nil,
+		),
+		WithStartTimeout(10*time.Millisecond),
+	)
+
+	// when
+	err := app.StartContext(context.Background())
+
+	// then
+	if !errors.Is(err, ErrComponentTimeout) {
+		t.Errorf("StartContext() error = '%v', want ErrComponentTimeout", err)
+	}
+
+}
+
+func TestApplication_StartPanic(t *testing.T) {
+
+	// given
+	app := NewApplication()
+
+	app.RegisterComponent(
+		NewComponent(
+			"broken",
+			func(ctx context.Context) error {
+				panic("boom")
+			},
+			nil,
+		),
+	)
+
+	// when
+	err := app.StartContext(context.Background())
+
+	// then
+	if !errors.Is(err, xsync.ErrRecovered) {
+		t.Errorf("StartContext() error = '%v', want xsync.ErrRecovered", err)
+	}
+
+}
+
+func TestApplication_InvalidDependencies(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		components func(app *Application)
+		expected   error
+	}{
+		{
+			name: "cycle",
+			components: func(app *Application) {
+				app.RegisterComponent(NewComponent("a", nil, nil), DependsOn("b"))
+				app.RegisterComponent(NewComponent("b", nil, nil), DependsOn("a"))
+			},
+			expected: ErrComponentCycle,
+		},
+		{
+			name: "unknown",
+			components: func(app *Application) {
+				app.RegisterComponent(NewComponent("a", nil, nil), DependsOn("missing"))
+			},
+			expected: ErrComponentUnknown,
+		},
+		{
+			name: "duplicate",
+			components: func(app *Application) {
+				app.RegisterComponent(NewComponent("a", nil, nil))
+				app.RegisterComponent(NewComponent("a", nil, nil))
+			},
+			expected: ErrComponentDuplicate,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			// given
+			app := NewApplication()
+			test.components(app)
+
+			// when
+			err := app.StartContext(context.Background())
+
+			// then
+			if !errors.Is(err, test.expected) {
+				t.Errorf("StartContext() error = '%v', want '%v'", err, test.expected)
+			}
+
+		})
+	}
+
+}
@@ -0,0 +1,32 @@
+package fw
+
+import (
+	"net"
+)
+
+// WithHttpListener
+// Serves HTTP on the provided listener instead of listening on the HTTP address,
+// the address of the listener is reported by HttpAddress.
+func WithHttpListener(listener net.Listener) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.httpListener = listener
+	}
+}
+
+// WithHttpsListener
+// Serves HTTPS on the provided listener instead of listening on the HTTPS address,
+// the address of the listener is reported by HttpsAddress.
+func WithHttpsListener(listener net.Listener) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.httpsListener = listener
+	}
+}
+
+// WithGrpcListener
+// Serves gRPC on the provided listener instead of listening on the gRPC address,
+// the address of the listener is reported by GrpcAddress.
+func WithGrpcListener(listener net.Listener) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.grpcListener = listener
+	}
+}
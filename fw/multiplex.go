@@ -0,0 +1,50 @@
+package fw
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const grpcContentType = "application/grpc"
+
+var ErrMultiplexing = errors.New("single port mode requires HTTP or HTTPS to be enabled")
+
+// WithMultiplexing
+// Serves gRPC on the HTTP and HTTPS ports instead of the gRPC port:
+// HTTP/1.1 and unencrypted HTTP/2 (h2c) share the HTTP listener,
+// HTTP/1.1 and HTTP/2 negotiated through ALPN share the HTTPS listener.
+func WithMultiplexing(enabled bool) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.multiplexed = enabled
+	}
+}
+
+func WithMultiplexingEnabled() ServerOp {
+	return func(settings *ServerSettings) {
+		settings.multiplexed = true
+	}
+}
+
+// Multiplex
+// Routes gRPC requests, HTTP/2 requests with the gRPC content type, to the gRPC handler
+// and any other request to the HTTP handler.
+func Multiplex(grpcHandler http.Handler, httpHandler http.Handler) http.Handler {
+
+	if httpHandler == nil {
+		httpHandler = http.DefaultServeMux
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isGRPC(r) {
+			grpcHandler.ServeHTTP(w, r)
+			return
+		}
+		httpHandler.ServeHTTP(w, r)
+	})
+
+}
+
+func isGRPC(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), grpcContentType)
+}
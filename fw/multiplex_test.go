@@ -0,0 +1,55 @@
+package fw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMultiplex(t *testing.T) {
+
+	// given
+	grpcHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	httpHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	handler := Multiplex(grpcHandler, httpHandler)
+
+	tests := []struct {
+		name        string
+		protoMajor  int
+		contentType string
+		expected    int
+	}{
+		{name: "grpc", protoMajor: 2, contentType: "application/grpc", expected: http.StatusAccepted},
+		{name: "grpc+proto", protoMajor: 2, contentType: "application/grpc+proto", expected: http.StatusAccepted},
+		{name: "http2", protoMajor: 2, contentType: "application/json", expected: http.StatusOK},
+		{name: "http1 grpc content type", protoMajor: 1, contentType: "application/grpc", expected: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			// given
+			request := httptest.NewRequest(http.MethodPost, "/service/Method", nil)
+			request.ProtoMajor = test.protoMajor
+			request.Header.Set("Content-Type", test.contentType)
+
+			recorder := httptest.NewRecorder()
+
+			// when
+			handler.ServeHTTP(recorder, request)
+
+			// then
+			if recorder.Code != test.expected {
+				t.Errorf("Multiplex() = %d, want %d", recorder.Code, test.expected)
+			}
+
+		})
+	}
+
+}
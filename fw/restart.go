@@ -0,0 +1,344 @@
+package fw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/evgenivanovi/gpl/std"
+	slogx "github.com/evgenivanovi/gpl/stdx/log/slog"
+)
+
+const DefaultRestartTimeout = 30 * time.Second
+
+const (
+	envRestartFDs     = "FW_LISTEN_FDS"
+	envRestartFDNames = "FW_LISTEN_FDNAMES"
+	envRestartReadyFD = "FW_READY_FD"
+)
+
+var ErrRestart = errors.New("could not restart")
+var ErrRestartUnavailable = errors.New("restart is not enabled or the application is not running")
+
+// RestartSettings
+// Controls the zero-downtime restart of RunServer: a new process of the executable is started
+// with the listeners of the servers, and once it reports readiness the current process shuts down gracefully.
+type RestartSettings struct {
+	// Enabled allows restarting by signals and Application.Restart.
+	Enabled bool
+	// Timeout limits the time the new process may take to become ready.
+	Timeout time.Duration
+	// Signals trigger the restart, SIGHUP by default.
+	Signals []os.Signal
+	// Command creates the command of the new process, the current executable with the same arguments by default.
+	Command func() (*exec.Cmd, error)
+}
+
+func defaultRestartSettings() RestartSettings {
+	return RestartSettings{
+		Enabled: false,
+		Timeout: DefaultRestartTimeout,
+		Signals: []os.Signal{syscall.SIGHUP},
+		Command: command,
+	}
+}
+
+// WithGracefulRestart
+// Enables the zero-downtime restart on SIGHUP and by Application.Restart.
+func WithGracefulRestart() ConfigurationOp {
+	return func(cfg *Configuration) {
+		cfg.Restart.Enabled = true
+	}
+}
+
+// WithRestartTimeout
+// Limits the time the new process may take to become ready, DefaultRestartTimeout by default.
+func WithRestartTimeout(timeout time.Duration) ConfigurationOp {
+	return func(cfg *Configuration) {
+		cfg.Restart.Timeout = timeout
+	}
+}
+
+// WithRestartSignals
+// Replaces the signals triggering the restart, SIGHUP by default.
+// Without signals the restart is triggered only by Application.Restart.
+func WithRestartSignals(signals ...os.Signal) ConfigurationOp {
+	return func(cfg *Configuration) {
+		cfg.Restart.Signals = signals
+	}
+}
+
+// WithRestartCommand
+// Creates the command of the new process, e.g. to start an upgraded executable.
+// The command must run RunServer with the restart enabled.
+func WithRestartCommand(fn func() (*exec.Cmd, error)) ConfigurationOp {
+	return func(cfg *Configuration) {
+		cfg.Restart.Command = fn
+	}
+}
+
+// Restart
+// Triggers the zero-downtime restart as a signal does and waits until the new process is ready
+// and the graceful shutdown of the current one is triggered, or the restart fails.
+// The current process keeps serving when the restart fails.
+func (a *Application) Restart(ctx context.Context) error {
+
+	if !a.restartable.Load() {
+		return ErrRestartUnavailable
+	}
+
+	reply := make(chan error, 1)
+
+	select {
+	case a.restarts <- reply:
+	case <-a.ShuttingDown():
+		return ErrRestartUnavailable
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-reply:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+}
+
+// InheritedListeners
+// Returns the listeners passed by the process restarting into the current one, see Application.Restart.
+// The listeners are created once per process.
+func InheritedListeners() ([]ActivatedListener, error) {
+	inheritance, err := inherited()
+	return inheritance.listeners, err
+}
+
+type inheritance struct {
+	listeners []ActivatedListener
+	ready     *os.File
+	once      sync.Once
+}
+
+var inherited = sync.OnceValues(inherit)
+
+func inherit() (*inheritance, error) {
+
+	result := &inheritance{}
+
+	rawCount, found := os.LookupEnv(envRestartFDs)
+	if !found {
+		return result, nil
+	}
+
+	rawNames := os.Getenv(envRestartFDNames)
+	rawReady := os.Getenv(envRestartReadyFD)
+
+	// Processes started by the current one must not inherit the descriptors.
+	_ = os.Unsetenv(envRestartFDs)
+	_ = os.Unsetenv(envRestartFDNames)
+	_ = os.Unsetenv(envRestartReadyFD)
+
+	listeners, err := fileListeners(rawCount, rawNames, ErrRestart)
+	if err != nil {
+		return result, err
+	}
+	result.listeners = listeners
+
+	if fd, err := strconv.Atoi(rawReady); err == nil {
+		result.ready = os.NewFile(uintptr(fd), "ready")
+	}
+
+	return result, nil
+
+}
+
+// notifyRestarted
+// Reports the readiness to the process that restarted into the current one.
+func notifyRestarted() {
+
+	inheritance, _ := inherited()
+	if inheritance == nil || inheritance.ready == nil {
+		return
+	}
+
+	inheritance.once.Do(func() {
+		_, _ = inheritance.ready.Write([]byte{1})
+		_ = inheritance.ready.Close()
+	})
+
+}
+
+func restarter(app *Application, settings RestartSettings) {
+
+	quit := make(chan os.Signal, 1)
+	if len(settings.Signals) > 0 {
+		signal.Notify(quit, settings.Signals...)
+		defer signal.Stop(quit)
+	}
+
+	for {
+
+		var reply chan error
+
+		select {
+		case sig := <-quit:
+			slogx.Log().Debug("Restarting server", slog.String("os.signal", sig.String()))
+		case reply = <-app.restarts:
+			slogx.Log().Debug("Restarting server on request")
+		case <-app.ShuttingDown():
+			return
+		}
+
+		err := handoff(app, settings)
+		if err != nil {
+			slogx.Log().Error("Could not restart server", slog.String("error", err.Error()))
+		}
+
+		if reply != nil {
+			reply <- err
+		}
+
+		// The new process serves from now on, the current one drains.
+		if err == nil {
+			app.requestShutdown()
+			return
+		}
+
+	}
+
+}
+
+// handoff
+// Starts the new process with the listeners and waits until it reports readiness through a pipe.
+func handoff(app *Application, settings RestartSettings) error {
+
+	select {
+	case <-app.Started():
+	default:
+		return fmt.Errorf("%w: the application is not started", ErrRestart)
+	}
+
+	names := make([]string, 0)
+	files := make([]*os.File, 0)
+	listeners := make([]net.Listener, 0)
+
+	defer func() {
+		for _, file := range files {
+			_ = file.Close()
+		}
+	}()
+
+	for _, slot := range app.Settings.slots() {
+
+		if !slot.enabled || *slot.listener == nil {
+			continue
+		}
+
+		file, err := listenerFile(*slot.listener)
+		if err != nil {
+			return fmt.Errorf("%w: %s listener: %w", ErrRestart, slot.name, err)
+		}
+
+		names = append(names, slot.name)
+		files = append(files, file)
+		listeners = append(listeners, *slot.listener)
+
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRestart, err)
+	}
+	defer reader.Close()
+
+	cmd, err := settings.Command()
+	if err != nil {
+		_ = writer.Close()
+		return fmt.Errorf("%w: %w", ErrRestart, err)
+	}
+
+	cmd.Env = append(
+		std.If(cmd.Env == nil, os.Environ(), cmd.Env),
+		envRestartFDs+"="+strconv.Itoa(len(files)),
+		envRestartFDNames+"="+strings.Join(names, std.Colon),
+		envRestartReadyFD+"="+strconv.Itoa(listenFDsStart+len(files)),
+	)
+	cmd.ExtraFiles = append(files, writer)
+
+	err = cmd.Start()
+	_ = writer.Close()
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRestart, err)
+	}
+
+	ready := make(chan error, 1)
+	go func() {
+		_, err := reader.Read(make([]byte, 1))
+		ready <- err
+	}()
+
+	timer := time.NewTimer(settings.Timeout)
+	defer timer.Stop()
+
+	select {
+	case err = <-ready:
+		if errors.Is(err, io.EOF) {
+			err = errors.New("the new process exited before becoming ready")
+		}
+	case <-timer.C:
+		err = fmt.Errorf("the new process did not become ready in %s", settings.Timeout)
+	}
+
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return fmt.Errorf("%w: %w", ErrRestart, err)
+	}
+
+	// The socket files are served by the new process from now on, they are kept when the listeners are closed,
+	// but only then, so that a failed restart still removes them on shutdown.
+	for _, listener := range listeners {
+		if unix, ok := listener.(*net.UnixListener); ok {
+			unix.SetUnlinkOnClose(false)
+		}
+	}
+
+	slogx.Log().Debug("Server restarted", slog.Int("pid", cmd.Process.Pid))
+
+	// The new process outlives the current one.
+	go func() {
+		_ = cmd.Wait()
+	}()
+
+	return nil
+
+}
+
+func command() (*exec.Cmd, error) {
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd, nil
+
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package fw
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+)
+
+// listenerFile
+// Listeners are handed over on unix systems only.
+func listenerFile(net.Listener) (*os.File, error) {
+	return nil, fmt.Errorf("listener handoff is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,203 @@
+package fw
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const restartHelperEnv = "FW_TEST_RESTART_HELPER"
+
+// TestApplication_Restart
+// The test process restarts into a copy of itself that serves the inherited listener.
+func TestApplication_Restart(t *testing.T) {
+
+	if os.Getenv(restartHelperEnv) != "" {
+		restartHelper(t)
+		return
+	}
+
+	// given
+	app := NewApplication()
+	app.Settings = *NewServerSettings(WithHttpEnabled(), WithHost("127.0.0.1"), WithHttpPort(0))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strconv.Itoa(os.Getpid())))
+	})
+
+	cfg := NewConfiguration(
+		WithApplication(app),
+		WithHTTPHandler(handler),
+		WithShutdownSignals(),
+		WithGracefulRestart(),
+		WithRestartSignals(),
+		WithRestartCommand(func() (*exec.Cmd, error) {
+			cmd := exec.Command(os.Args[0], "-test.run=^TestApplication_Restart$")
+			cmd.Env = append(os.Environ(), restartHelperEnv+"=1")
+			return cmd, nil
+		}),
+	)
+
+	result := make(chan error, 1)
+	go func() {
+		result <- RunServer(cfg)
+	}()
+
+	waitReady(t, app)
+	address := "http://" + app.Settings.HttpAddress() + "/pid"
+
+	// when
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err := app.Restart(ctx)
+
+	// then
+	if err != nil {
+		t.Fatalf("Restart() unexpected error: %v", err)
+	}
+
+	if err := <-result; err != nil {
+		t.Fatalf("RunServer() unexpected error: %v", err)
+	}
+
+	response, err := http.Get(address)
+	if err != nil {
+		t.Fatalf("GET after restart unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+
+	if pid := string(body); pid == strconv.Itoa(os.Getpid()) || pid == "" {
+		t.Errorf("GET after restart served by pid '%s', want the new process", pid)
+	}
+
+}
+
+// restartHelper
+// Serves the inherited listener until the first request is answered.
+func restartHelper(t *testing.T) {
+
+	app := NewApplication()
+	app.Settings = *NewServerSettings(WithHttpEnabled(), WithHost("127.0.0.1"), WithHttpPort(0))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strconv.Itoa(os.Getpid())))
+		go app.requestShutdown()
+	})
+
+	cfg := NewConfiguration(
+		WithApplication(app),
+		WithHTTPHandler(handler),
+		WithShutdownSignals(),
+		WithGracefulRestart(),
+		WithShutdownTimeout(time.Second),
+	)
+
+	// The helper must not outlive the test.
+	time.AfterFunc(20*time.Second, app.requestShutdown)
+
+	if err := RunServer(cfg); err != nil {
+		t.Fatalf("RunServer() in the restarted process: %v", err)
+	}
+
+}
+
+func TestApplication_RestartFailure(t *testing.T) {
+
+	// given
+	app := NewApplication()
+	app.Settings = *NewServerSettings(WithHttpEnabled(), WithHost("127.0.0.1"), WithHttpPort(0))
+
+	cfg := NewConfiguration(
+		WithApplication(app),
+		WithShutdownSignals(),
+		WithGracefulRestart(),
+		WithRestartSignals(),
+		WithRestartCommand(func() (*exec.Cmd, error) {
+			return exec.Command(os.Args[0], "-test.run=^$"), nil
+		}),
+	)
+
+	result := make(chan error, 1)
+	go func() {
+		result <- RunServer(cfg)
+	}()
+
+	waitReady(t, app)
+
+	t.Cleanup(func() {
+		_ = app.Shutdown(context.Background())
+		<-result
+	})
+
+	// when
+	err := app.Restart(context.Background())
+
+	// then
+	if !errors.Is(err, ErrRestart) {
+		t.Errorf("Restart() error = %v, want %v", err, ErrRestart)
+	}
+
+	select {
+	case <-app.ShuttingDown():
+		t.Error("Restart() failed but the application is shutting down")
+	default:
+	}
+
+	if unavailable := NewApplication().Restart(context.Background()); !errors.Is(unavailable, ErrRestartUnavailable) {
+		t.Errorf("Restart() error = %v, want %v", unavailable, ErrRestartUnavailable)
+	}
+
+}
+
+func TestApplication_RestartFailureUnixSocket(t *testing.T) {
+
+	// given
+	socket := filepath.Join(t.TempDir(), "http.sock")
+
+	app := NewApplication()
+	app.Settings = *NewServerSettings(WithHttpEnabled(), WithHttpUnixSocket(socket, 0o600))
+
+	cfg := NewConfiguration(
+		WithApplication(app),
+		WithShutdownSignals(),
+		WithGracefulRestart(),
+		WithRestartSignals(),
+		WithRestartCommand(func() (*exec.Cmd, error) {
+			return exec.Command(os.Args[0], "-test.run=^$"), nil
+		}),
+	)
+
+	result := make(chan error, 1)
+	go func() {
+		result <- RunServer(cfg)
+	}()
+
+	waitReady(t, app)
+
+	if err := app.Restart(context.Background()); !errors.Is(err, ErrRestart) {
+		t.Fatalf("Restart() error = %v, want %v", err, ErrRestart)
+	}
+
+	// when
+	err := app.Shutdown(context.Background())
+	<-result
+
+	// then
+	if err != nil {
+		t.Fatalf("Shutdown() unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Errorf("socket file is kept after a failed restart, stat error = %v", err)
+	}
+
+}
@@ -0,0 +1,46 @@
+//go:build unix
+
+package fw
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+// listenerFile
+// Duplicates the descriptor of the listener.
+// The duplicate is created with os.NewFile, unlike the one of TCPListener.File,
+// so that passing it to the new process keeps the listener of the current process non-blocking.
+func listenerFile(listener net.Listener) (*os.File, error) {
+
+	conn, ok := listener.(syscall.Conn)
+	if !ok {
+		return nil, fmt.Errorf("listener of type %T has no descriptor", listener)
+	}
+
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	dup := -1
+	var dupErr error
+
+	err = raw.Control(func(fd uintptr) {
+		dup, dupErr = syscall.Dup(int(fd))
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if dupErr != nil {
+		return nil, dupErr
+	}
+
+	syscall.CloseOnExec(dup)
+	return os.NewFile(uintptr(dup), listener.Addr().String()), nil
+
+}
@@ -2,64 +2,156 @@ package fw
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"syscall"
+	"slices"
 	"time"
 
 	grpcfw "github.com/evgenivanovi/gpl/server"
+	"github.com/evgenivanovi/gpl/std"
 	slogx "github.com/evgenivanovi/gpl/stdx/log/slog"
+	me "github.com/hashicorp/go-multierror"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // RunServer
-// When we receive a SIGINT or SIGTERM signal,
+// When we receive a SIGINT or SIGTERM signal, or another configured one, or Application.Shutdown is called,
 // we instruct our server to stop accepting any new HTTP(s) requests,
 // and give any in-flight requests a ‘grace period’ to complete before the application is terminated.
+//
+// The components of the application are started before the servers and stopped after them,
+// a component failing to start aborts RunServer with the error.
+//
+// The admin server, when enabled, is started first and stopped last,
+// so that the application can be inspected while it starts and stops.
+//
+// When the restart is enabled, SIGHUP or Application.Restart starts a new process with the listeners
+// of the servers and shuts the current one down gracefully once the new one is ready.
 func RunServer(cfg *Configuration) error {
 
-	var httpServer *http.Server = nil
-	if cfg.App.Settings.HttpEnabled() {
-		httpServer = &http.Server{
-			Addr:    cfg.App.Settings.HttpAddress(),
-			Handler: cfg.HTTPHandler,
-		}
+	cfg.apply()
+
+	// Misconfiguration is reported at once, before anything is started.
+	if err := cfg.App.Config.Validate(); err != nil {
+		slogx.Log().Debug(
+			fmt.Sprintf("Could not start service due to invalid configuration (error: %s)", err),
+		)
+		return err
 	}
 
-	var httpsServer *http.Server = nil
-	if cfg.App.Settings.HttpsEnabled() {
-		httpsServer = &http.Server{
-			Addr:      cfg.App.Settings.HttpsAddress(),
-			Handler:   cfg.HTTPHandler,
-			TLSConfig: cfg.App.Settings.tls.config,
+	// Unix and activated sockets are opened before the servers are created,
+	// so that the servers and their addresses use them.
+	if err := cfg.App.Settings.listen(); err != nil {
+		slogx.Log().Debug(
+			fmt.Sprintf("Could not start service due to unavailable sockets (error: %s)", err),
+		)
+		return err
+	}
+
+	// The servers close the listeners they serve, the rest are closed when the servers do not start.
+	defer cfg.App.Settings.closeListeners()
+
+	handler := cfg.HTTPHandler
+	grpcServices := cfg.GRPCServices
+	if cfg.HealthEndpoints {
+		handler = cfg.App.Health.Handler(handler)
+		grpcServices = append(slices.Clip(grpcServices), cfg.App.Health.GRPCService())
+	}
+
+	// The certificate is shared by the HTTPS and gRPC servers, each gets its own copy of the configuration.
+	var tlsConfig *tls.Config = nil
+	if cfg.App.Settings.HttpsEnabled() || cfg.App.Settings.GrpcTLSEnabled() {
+		config, err := cfg.App.Settings.tls.Config()
+		if err != nil {
+			slogx.Log().Debug(
+				fmt.Sprintf("Could not start service due to invalid TLS settings (error: %s)", err),
+			)
+			return err
 		}
+		tlsConfig = config
+	}
+
+	var grpcTLSConfig *tls.Config = nil
+	if cfg.App.Settings.GrpcTLSEnabled() {
+		grpcTLSConfig = tlsConfig.Clone()
 	}
 
 	var grpcServer *grpcfw.GRPCServer = nil
 	if cfg.App.Settings.GrpcEnabled() {
-		grpcServer = grpcfw.NewGrpcServer(
+		grpcOps := []grpcfw.GRPCServerOp{
 			grpcfw.WithGrpcServerConfig(
-				*grpcfw.NewGRPCServerConfig(cfg.App.Settings.grpcPort),
+				*grpcfw.NewGRPCServerAddressConfig(cfg.App.Settings.host, cfg.App.Settings.grpcPort),
 			),
+			grpcfw.WithListener(cfg.App.Settings.grpcListener),
+			grpcfw.WithTLSConfig(grpcTLSConfig),
 			grpcfw.WithGrpcReflection(cfg.GRPCReflection),
-			grpcfw.WithServices(cfg.GRPCServices...),
+			grpcfw.WithServices(grpcServices...),
 			grpcfw.WithUnaryInterceptors(cfg.GRPCUnaryMWs...),
 			grpcfw.WithStreamInterceptors(cfg.GRPCStreamMWs...),
-		)
+		}
+		grpcServer = grpcfw.NewGrpcServer(append(grpcOps, cfg.GRPCServerOps...)...)
+	}
+
+	if cfg.App.Settings.Multiplexed() {
+		if !cfg.App.Settings.HttpEnabled() && !cfg.App.Settings.HttpsEnabled() {
+			return ErrMultiplexing
+		}
+		if grpcServer != nil {
+			handler = Multiplex(grpcServer, handler)
+		}
+	}
+
+	// Unencrypted HTTP/2 is accepted only when gRPC shares the port,
+	// under TLS HTTP/2 is negotiated through ALPN by the server itself.
+	plainHandler := handler
+	if cfg.App.Settings.Multiplexed() {
+		plainHandler = h2c.NewHandler(handler, &http2.Server{})
+	}
+
+	var httpServer *http.Server = nil
+	if cfg.App.Settings.HttpEnabled() {
+		httpServer = newHTTPServer(cfg.App, cfg.App.Settings.HttpAddress(), plainHandler)
+	}
+
+	var httpsServer *http.Server = nil
+	if cfg.App.Settings.HttpsEnabled() {
+		httpsServer = newHTTPServer(cfg.App, cfg.App.Settings.HttpsAddress(), handler)
+		httpsServer.TLSConfig = tlsConfig.Clone()
+	}
+
+	var adminServer *http.Server = nil
+	if cfg.App.Settings.AdminEnabled() {
+		// Profiles and traces are written for as long as requested, the write timeout does not apply.
+		adminServer = newHTTPServer(cfg.App, cfg.App.Settings.AdminAddress(), AdminHandler(cfg.App, cfg.AdminRoutes))
+		adminServer.ReadTimeout = 0
+		adminServer.WriteTimeout = 0
 	}
 
 	// Create a startErrorCh channel.
 	// We will use this to receive any errors returned by the Startup() function.
-	startErrorCh := make(chan error)
+	// It is buffered for every server and the startup itself, so that no sender blocks
+	// after RunServer has returned.
+	startErrorCh := make(chan error, 5)
 	// Create a shutdownErrorCh channel.
-	// We will use this to receive any errors returned by the Shutdown() function.
-	shutdownErrorCh := make(chan error)
+	// We will use this to receive the result of the Shutdown() function.
+	shutdownErrorCh := make(chan error, 1)
+
+	// Closed when the startup is over, so that the shutdown never races with it.
+	started := make(chan struct{})
 
-	go shutdown(cfg.App, shutdownErrorCh, httpServer, httpsServer, grpcServer)
-	go startup(cfg.App, startErrorCh, httpServer, httpsServer, grpcServer)
+	if cfg.Restart.Enabled {
+		cfg.App.restartable.Store(true)
+		go restarter(cfg.App, cfg.Restart)
+	}
+
+	go shutdown(cfg.App, cfg.Shutdown, started, shutdownErrorCh, httpServer, httpsServer, grpcServer, adminServer)
+	go startup(cfg.App, started, startErrorCh, httpServer, httpsServer, grpcServer, adminServer)
 
 	// Calling Shutdown() on our HTTP servers will cause ListenAndServe() or ListenAndServerTLS()
 	// to immediately return a http.ErrServerClosed error.
@@ -70,13 +162,14 @@ func RunServer(cfg *Configuration) error {
 	select {
 	case err := <-startErrorCh:
 		{
-			if err != nil {
-				slogx.Log().Debug(
-					fmt.Sprintf("Could not start service due to (error: %s)", err),
-				)
-				return err
-			}
-			break
+			slogx.Log().Debug(
+				fmt.Sprintf("Could not start service due to (error: %s)", err),
+			)
+			// Stop the servers that did start and close the application.
+			cfg.App.requestShutdown()
+			errs := newErrors()
+			errs = me.Append(errs, err, <-shutdownErrorCh)
+			return errs.ErrorOrNil()
 		}
 	case err := <-shutdownErrorCh:
 		{
@@ -98,13 +191,37 @@ func RunServer(cfg *Configuration) error {
 
 func startup(
 	app *Application,
+	started chan struct{},
 	errs chan error,
 	httpServer *http.Server,
 	httpsServer *http.Server,
 	grpcServer *grpcfw.GRPCServer,
+	adminServer *http.Server,
 ) {
 
-	app.Start()
+	defer close(started)
+
+	// Nothing is started once the shutdown has been requested.
+	select {
+	case <-app.ShuttingDown():
+		return
+	default:
+	}
+
+	if app.Settings.adminEnabled && adminServer != nil {
+		go startAdminServer(
+			app,
+			errs,
+			adminServer,
+		)
+	}
+
+	// Components are started before the servers accept any request,
+	// a failure aborts the startup with every error that occurred.
+	if err := app.StartContext(context.Background()); err != nil {
+		errs <- err
+		return
+	}
 
 	if app.Settings.httpEnabled && httpServer != nil {
 		go startHttpServer(
@@ -122,7 +239,8 @@ func startup(
 		)
 	}
 
-	if app.Settings.grpcEnabled && grpcServer != nil {
+	// In the single port mode gRPC is served by the HTTP servers.
+	if app.Settings.grpcEnabled && grpcServer != nil && !app.Settings.multiplexed {
 		go startGrpcServer(
 			app,
 			errs,
@@ -130,6 +248,11 @@ func startup(
 		)
 	}
 
+	// A shutdown requested while starting must not be overridden.
+	if app.markStarted() {
+		notifyRestarted()
+	}
+
 }
 
 func startHttpServer(
@@ -146,7 +269,12 @@ func startHttpServer(
 		"HTTP server has been run on address: '" + server.Addr + "'",
 	)
 
-	err := server.ListenAndServe()
+	var err error
+	if app.Settings.httpListener != nil {
+		err = server.Serve(app.Settings.httpListener)
+	} else {
+		err = server.ListenAndServe()
+	}
 
 	// Calling Shutdown() on our HTTP servers will cause ListenAndServe() or ListenAndServerTLS()
 	// to immediately return a http.ErrServerClosed error.
@@ -173,10 +301,13 @@ func startHttpsServer(
 		"HTTPs server has been run on address: '" + server.Addr + "'",
 	)
 
-	err := server.ListenAndServeTLS(
-		app.Settings.tls.cert,
-		app.Settings.tls.key,
-	)
+	// The certificate is provided by the TLS configuration of the server.
+	var err error
+	if app.Settings.httpsListener != nil {
+		err = server.ServeTLS(app.Settings.httpsListener, std.Empty, std.Empty)
+	} else {
+		err = server.ListenAndServeTLS(std.Empty, std.Empty)
+	}
 
 	// Calling Shutdown() on our HTTP servers will cause ListenAndServe() or ListenAndServerTLS()
 	// to immediately return a http.ErrServerClosed error.
@@ -189,6 +320,34 @@ func startHttpsServer(
 
 }
 
+func startAdminServer(
+	app *Application,
+	errs chan error,
+	server *http.Server,
+) {
+
+	if !app.Settings.adminEnabled {
+		return
+	}
+
+	slogx.Log().Debug(
+		"Admin server has been run on address: '" + server.Addr + "'",
+	)
+
+	var err error
+	if app.Settings.adminListener != nil {
+		err = server.Serve(app.Settings.adminListener)
+	} else {
+		err = server.ListenAndServe()
+	}
+
+	if !errors.Is(err, http.ErrServerClosed) {
+		errs <- err
+		return
+	}
+
+}
+
 func startGrpcServer(
 	app *Application,
 	errs chan error,
@@ -209,10 +368,13 @@ func startGrpcServer(
 
 func shutdown(
 	app *Application,
+	settings ShutdownSettings,
+	started chan struct{},
 	errs chan error,
 	httpServer *http.Server,
 	httpsServer *http.Server,
 	grpcServer *grpcfw.GRPCServer,
+	adminServer *http.Server,
 ) {
 
 	httpServers := make([]*http.Server, 0)
@@ -231,21 +393,29 @@ func shutdown(
 		grpcServers = append(grpcServers, grpcServer)
 	}
 
-	doShutdown(app, errs, grpcServers, httpServers)
+	if !app.Settings.adminEnabled {
+		adminServer = nil
+	}
+
+	err := doShutdown(app, settings, started, grpcServers, httpServers, adminServer)
+	app.completeShutdown(err)
+	errs <- err
 
 }
 
 func doShutdown(
 	app *Application,
-	errs chan error,
+	settings ShutdownSettings,
+	started chan struct{},
 	grpcServers []*grpcfw.GRPCServer,
 	httpServers []*http.Server,
-) {
+	adminServer *http.Server,
+) error {
 
 	// Create a quit channel which carries os.Signal values.
 	quit := make(chan os.Signal, 1)
 
-	// Use signal.Notify() to listen for incoming syscall.SIGINT and syscall.SIGTERM signals and
+	// Use signal.Notify() to listen for the configured signals, SIGINT and SIGTERM by default, and
 	// relay them to the quitChannel channel.
 	// Any other signals will not be caught by signal.Notify() and
 	// will retain their default behavior.
@@ -254,45 +424,89 @@ func doShutdown(
 	// (and will always cause the application to terminate immediately),
 	// and we’ll leave SIGQUIT with its default behavior
 	// (as it’s handy if you want to execute a non-graceful shutdown via a keyboard shortcut).
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-
-	// Read the signal from the quitChannel channel.
-	// This code will block until a signal is received.
-	sig := <-quit
+	if len(settings.Signals) > 0 {
+		signal.Notify(quit, settings.Signals...)
+		defer signal.Stop(quit)
+	}
 
-	// Log a message to say that the signal has been caught.
+	// Block until a signal is received or the shutdown is requested programmatically.
+	// Log a message to say that the shutdown has been triggered.
 	// Notice that we also call the String() method on the signal to get the signal name and
 	// include it in the log entry properties.
-	slogx.Log().Debug(
-		"Shutting down server",
-		slog.String("os.signal", sig.String()),
-	)
+	select {
+	case sig := <-quit:
+		slogx.Log().Debug(
+			"Shutting down server",
+			slog.String("os.signal", sig.String()),
+		)
+	case <-app.ShuttingDown():
+		slogx.Log().Debug("Shutting down server on request")
+	}
+
+	app.requestShutdown()
+
+	// Report not ready at once, so that load balancers stop routing new requests
+	// while in-flight requests are completed.
+	app.Health.SetReady(false)
 
-	// Create a context with a timeout.
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Create a context limiting the whole shutdown.
+	ctx, cancel := phase(context.Background(), settings.Timeout)
 	defer cancel()
 
-	// Call Shutdown() on our server, passing in the context we just made.
+	// Give load balancers time to notice the readiness change before connections are refused.
+	if settings.DrainDelay > 0 {
+		slogx.Log().Debug("Draining server", slog.Duration("delay", settings.DrainDelay))
+		select {
+		case <-time.After(settings.DrainDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	errs := newErrors()
+
+	// Wait for the startup to finish, so that every started component is stopped.
+	select {
+	case <-started:
+	case <-ctx.Done():
+		errs = me.Append(errs, fmt.Errorf("wait for startup: %w", ctx.Err()))
+	}
+
+	// Call Shutdown() on our servers, passing in the context of the phase.
 	// Shutdown() will return nil if the graceful executor was successful, or an
 	// error (which may happen because of a problem closing the listeners, or
 	// because the executor didn't complete before the context deadline is hit).
-	// We relay this return value to the shutdownError channel.
+	// gRPC servers are stopped forcefully once the deadline is hit.
+	serversCtx, serversCancel := phase(ctx, settings.ServersTimeout)
+	defer serversCancel()
+
 	for _, server := range httpServers {
-		err := server.Shutdown(ctx)
-		if err != nil {
-			errs <- err
+		if err := server.Shutdown(serversCtx); err != nil {
+			errs = me.Append(errs, fmt.Errorf("shutdown http server '%s': %w", server.Addr, err))
 		}
 	}
 
 	for _, server := range grpcServers {
-		server.Stop()
+		if err := server.StopContext(serversCtx); err != nil {
+			errs = me.Append(errs, fmt.Errorf("stop grpc server: %w", err))
+		}
 	}
 
 	slogx.Log().Debug("Completing tasks")
-	app.Close()
 
-	// Then we return nil on the shutdownError channel,
-	// to indicate that the executor was completed without any issues.
-	errs <- nil
+	closeCtx, closeCancel := phase(ctx, settings.CloseTimeout)
+	defer closeCancel()
+
+	if err := app.CloseContext(closeCtx); err != nil {
+		errs = me.Append(errs, err)
+	}
+
+	// The admin server answers until everything else is stopped.
+	if adminServer != nil {
+		if err := adminServer.Shutdown(ctx); err != nil {
+			errs = me.Append(errs, fmt.Errorf("shutdown admin server '%s': %w", adminServer.Addr, err))
+		}
+	}
+
+	return errs.ErrorOrNil()
 
 }
@@ -1,8 +1,8 @@
 package fw
 
 import (
+	"net"
 	"strconv"
-	"strings"
 
 	"github.com/evgenivanovi/gpl/std"
 	"github.com/gookit/goutil/strutil"
@@ -31,9 +31,11 @@ func (o ServerOp) And(ops ...ServerOp) ServerOp {
 	}
 }
 
+// WithHost
+// Sets the host of the servers, an IPv6 address may be given in brackets.
 func WithHost(host string) ServerOp {
 	return func(settings *ServerSettings) {
-		settings.host = host
+		settings.host = trimBrackets(host)
 	}
 }
 
@@ -137,45 +139,63 @@ func WithGrpcPortFn(fn func() int) ServerOp {
 	return WithGrpcPort(fn())
 }
 
+// WithHttpAddress
+// Sets the host and, when given, the port, e.g. 'localhost:8080' or '[::1]:8080'.
+// It panics on an invalid address, see SplitAddress for an error instead.
 func WithHttpAddress(address string) ServerOp {
-	host, port, found := strings.Cut(address, std.Colon)
+	host, port, err := SplitAddress(address)
+	if err != nil {
+		panic(err)
+	}
 
-	if !found {
-		return WithHost(address)
+	if port < 0 {
+		return WithHost(host)
 	}
 
 	return WithHost(host).
-		Join(WithStringHttpPort(port))
+		Join(WithHttpPort(port))
 }
 
 func WithHttpAddressFn(fn func() string) ServerOp {
 	return WithHttpAddress(fn())
 }
 
+// WithHttpsAddress
+// Sets the host and, when given, the port, e.g. 'localhost:8080' or '[::1]:8080'.
+// It panics on an invalid address, see SplitAddress for an error instead.
 func WithHttpsAddress(address string) ServerOp {
-	host, port, found := strings.Cut(address, std.Colon)
+	host, port, err := SplitAddress(address)
+	if err != nil {
+		panic(err)
+	}
 
-	if !found {
-		return WithHost(address)
+	if port < 0 {
+		return WithHost(host)
 	}
 
 	return WithHost(host).
-		Join(WithStringHttpsPort(port))
+		Join(WithHttpsPort(port))
 }
 
 func WithHttpsAddressFn(fn func() string) ServerOp {
 	return WithHttpsAddress(fn())
 }
 
+// WithGrpcAddress
+// Sets the host and, when given, the port, e.g. 'localhost:8080' or '[::1]:8080'.
+// It panics on an invalid address, see SplitAddress for an error instead.
 func WithGrpcAddress(address string) ServerOp {
-	host, port, found := strings.Cut(address, std.Colon)
+	host, port, err := SplitAddress(address)
+	if err != nil {
+		panic(err)
+	}
 
-	if !found {
-		return WithHost(address)
+	if port < 0 {
+		return WithHost(host)
 	}
 
 	return WithHost(host).
-		Join(WithStringGrpcPort(port))
+		Join(WithGrpcPort(port))
 }
 
 func WithGrpcAddressFn(fn func() string) ServerOp {
@@ -200,7 +220,29 @@ type ServerSettings struct {
 	grpcPort    int
 	grpcEnabled bool
 
+	multiplexed bool
+
+	grpcTLS bool
+
+	adminPort    int
+	adminEnabled bool
+
 	tls *TLS
+
+	http HTTPSettings
+
+	httpListener  net.Listener
+	httpsListener net.Listener
+	grpcListener  net.Listener
+	adminListener net.Listener
+	// listened are the listeners created or assigned by listen, closed by closeListeners.
+	listened []*net.Listener
+
+	httpSocket  *unixSocket
+	httpsSocket *unixSocket
+	grpcSocket  *unixSocket
+	adminSocket *unixSocket
+	activation  bool
 }
 
 func (ss ServerSettings) HttpEnabled() bool {
@@ -215,28 +257,43 @@ func (ss ServerSettings) GrpcEnabled() bool {
 	return ss.grpcEnabled
 }
 
+func (ss ServerSettings) Multiplexed() bool {
+	return ss.multiplexed
+}
+
 func (ss ServerSettings) HttpAddress() string {
-	res := strings.Builder{}
-	res.WriteString(ss.host)
-	res.WriteString(std.Colon)
-	res.WriteString(strconv.Itoa(ss.httpPort))
-	return res.String()
+	if ss.httpListener != nil {
+		return listenerAddress(ss.httpListener)
+	}
+	if ss.httpSocket != nil {
+		return ss.httpSocket.address()
+	}
+	return net.JoinHostPort(ss.host, strconv.Itoa(ss.httpPort))
 }
 
 func (ss ServerSettings) HttpsAddress() string {
-	res := strings.Builder{}
-	res.WriteString(ss.host)
-	res.WriteString(std.Colon)
-	res.WriteString(strconv.Itoa(ss.httpsPort))
-	return res.String()
+	if ss.httpsListener != nil {
+		return listenerAddress(ss.httpsListener)
+	}
+	if ss.httpsSocket != nil {
+		return ss.httpsSocket.address()
+	}
+	return net.JoinHostPort(ss.host, strconv.Itoa(ss.httpsPort))
 }
 
+// GrpcAddress
+// Returns the address gRPC is served on, the HTTP or else the HTTPS address in the single port mode.
 func (ss ServerSettings) GrpcAddress() string {
-	res := strings.Builder{}
-	res.WriteString(ss.host)
-	res.WriteString(std.Colon)
-	res.WriteString(strconv.Itoa(ss.grpcPort))
-	return res.String()
+	if ss.multiplexed {
+		return std.If(ss.httpEnabled, ss.HttpAddress(), ss.HttpsAddress())
+	}
+	if ss.grpcListener != nil {
+		return listenerAddress(ss.grpcListener)
+	}
+	if ss.grpcSocket != nil {
+		return ss.grpcSocket.address()
+	}
+	return net.JoinHostPort(ss.host, strconv.Itoa(ss.grpcPort))
 }
 
 func NewServerSettings(ops ...ServerOp) *ServerSettings {
@@ -260,6 +317,11 @@ func defaultServerSettings() ServerSettings {
 		grpcPort:    ServerDefaultGRPCPort,
 		grpcEnabled: false,
 
+		adminPort:    ServerDefaultAdminPort,
+		adminEnabled: false,
+
 		tls: NewTLS(),
+
+		http: defaultHTTPSettings(),
 	}
 }
@@ -0,0 +1,285 @@
+package fw
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/evgenivanovi/gpl/cfg"
+	"github.com/evgenivanovi/gpl/std"
+	"github.com/evgenivanovi/gpl/stdx/mex"
+	me "github.com/hashicorp/go-multierror"
+)
+
+var ErrServerSettings = errors.New("invalid server settings")
+
+const (
+	ClientAuthNone             = "none"
+	ClientAuthRequest          = "request"
+	ClientAuthRequire          = "require"
+	ClientAuthVerifyIfGiven    = "verify-if-given"
+	ClientAuthRequireAndVerify = "require-and-verify"
+)
+
+const (
+	TLSPresetNameIntermediate = "intermediate"
+	TLSPresetNameModern       = "modern"
+)
+
+// ServerConfig
+// Standard layout of the server properties, bound by ServerSettingsFromConfig.
+// Every property is read from the flag, the environment variable and the key of the configuration files,
+// in this order, and falls back to the same defaults as NewServerSettings.
+type ServerConfig struct {
+	Host string `cfg:"server.host" env:"SERVER_HOST" flag:"server-host" default:"localhost"`
+
+	HTTPEnabled bool `cfg:"server.http.enabled" env:"SERVER_HTTP_ENABLED" flag:"server-http-enabled" default:"false"`
+	HTTPPort    int  `cfg:"server.http.port" env:"SERVER_HTTP_PORT" flag:"server-http-port" default:"80"`
+
+	HTTPSEnabled bool `cfg:"server.https.enabled" env:"SERVER_HTTPS_ENABLED" flag:"server-https-enabled" default:"false"`
+	HTTPSPort    int  `cfg:"server.https.port" env:"SERVER_HTTPS_PORT" flag:"server-https-port" default:"443"`
+
+	GRPCEnabled bool `cfg:"server.grpc.enabled" env:"SERVER_GRPC_ENABLED" flag:"server-grpc-enabled" default:"false"`
+	GRPCPort    int  `cfg:"server.grpc.port" env:"SERVER_GRPC_PORT" flag:"server-grpc-port" default:"82"`
+	GRPCTLS     bool `cfg:"server.grpc.tls" env:"SERVER_GRPC_TLS" flag:"server-grpc-tls" default:"false"`
+
+	Multiplexed bool `cfg:"server.multiplexed" env:"SERVER_MULTIPLEXED" flag:"server-multiplexed" default:"false"`
+
+	AdminEnabled bool `cfg:"server.admin.enabled" env:"SERVER_ADMIN_ENABLED" flag:"server-admin-enabled" default:"false"`
+	AdminPort    int  `cfg:"server.admin.port" env:"SERVER_ADMIN_PORT" flag:"server-admin-port" default:"9090"`
+
+	TLSCert       string   `cfg:"server.tls.cert" env:"SERVER_TLS_CERT" flag:"server-tls-cert" default:""`
+	TLSKey        string   `cfg:"server.tls.key" env:"SERVER_TLS_KEY" flag:"server-tls-key" default:""`
+	TLSClientCA   []string `cfg:"server.tls.client_ca" env:"SERVER_TLS_CLIENT_CA" flag:"server-tls-client-ca" default:""`
+	TLSClientAuth string   `cfg:"server.tls.client_auth" env:"SERVER_TLS_CLIENT_AUTH" flag:"server-tls-client-auth" default:""`
+	TLSPreset     string   `cfg:"server.tls.preset" env:"SERVER_TLS_PRESET" flag:"server-tls-preset" default:""`
+}
+
+// DefineServerFlags
+// Defines the flags of ServerConfig in the flag set of the caller, e.g. cfg.WithFlagSet or cfg.WithPFlagSet,
+// so that the caller parses them together with its own flags before ServerSettingsFromConfig.
+func DefineServerFlags(flags cfg.ArgSourceOp) {
+	// Only the flags are of interest, the values are read by ServerSettingsFromConfig.
+	_ = cfg.Bind(&ServerConfig{}, cfg.WithBindRegistry(cfg.NewRegistry()), cfg.WithBindArgOps(flags))
+}
+
+// ServerSettingsFromConfig
+// Binds ServerConfig with the flags of the flag set of the caller, see DefineServerFlags,
+// and the options, e.g. cfg.WithBindFile, and validates it.
+// The flag set is not parsed here, flags missing from it are defined and read as not set.
+// Every invalid property is reported in the single returned error.
+func ServerSettingsFromConfig(flags cfg.ArgSourceOp, ops ...cfg.BindOp) (*ServerSettings, error) {
+
+	config := ServerConfig{}
+	if err := cfg.Bind(&config, append(ops, cfg.WithBindArgOps(flags))...); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrServerSettings, err)
+	}
+
+	return config.Settings()
+
+}
+
+// Settings
+// Validates the properties and converts them to ServerSettings.
+func (c ServerConfig) Settings() (*ServerSettings, error) {
+
+	errs := &me.Error{
+		ErrorFormat: mex.AppendFormat(std.SemicolonSpace),
+	}
+
+	invalid := func(property string, format string, args ...any) {
+		errs = me.Append(errs, fmt.Errorf("%w: %s: %s", ErrServerSettings, property, fmt.Sprintf(format, args...)))
+	}
+
+	if err := ValidateHost(c.Host); err != nil {
+		invalid("server.host", "%s", err)
+	}
+
+	ports := map[string]int{
+		"server.http.port":  c.HTTPPort,
+		"server.https.port": c.HTTPSPort,
+		"server.grpc.port":  c.GRPCPort,
+		"server.admin.port": c.AdminPort,
+	}
+
+	for property, port := range ports {
+		if err := ValidatePort(port); err != nil {
+			invalid(property, "%s", err)
+		}
+	}
+
+	tlsOps := make([]TLSOp, 0)
+
+	if c.TLSCert != std.Empty || c.TLSKey != std.Empty {
+		tlsOps = append(tlsOps, WithCertKey(c.TLSCert, c.TLSKey))
+	}
+
+	if len(c.TLSClientCA) != 0 {
+		tlsOps = append(tlsOps, WithClientCA(c.TLSClientCA...))
+	}
+
+	if c.TLSClientAuth != std.Empty {
+		auth, err := parseClientAuth(c.TLSClientAuth)
+		if err != nil {
+			invalid("server.tls.client_auth", "%s", err)
+		}
+		tlsOps = append(tlsOps, WithClientAuth(auth))
+	}
+
+	if c.TLSPreset != std.Empty {
+		preset, err := parsePreset(c.TLSPreset)
+		if err != nil {
+			invalid("server.tls.preset", "%s", err)
+		}
+		tlsOps = append(tlsOps, WithPreset(preset))
+	}
+
+	if err := errs.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+
+	return NewServerSettings(
+		WithHost(c.Host),
+		WithHttp(c.HTTPEnabled),
+		WithHttpPort(c.HTTPPort),
+		WithHttps(c.HTTPSEnabled),
+		WithHttpsPort(c.HTTPSPort),
+		WithGrpc(c.GRPCEnabled),
+		WithGrpcPort(c.GRPCPort),
+		WithGrpcTLS(c.GRPCTLS),
+		WithMultiplexing(c.Multiplexed),
+		WithAdmin(c.AdminEnabled),
+		WithAdminPort(c.AdminPort),
+		WithTLS(func() *TLS { return NewTLS(tlsOps...) }),
+	), nil
+
+}
+
+// ValidateHost
+// Accepts an empty host, listening on every interface, an IP address, also in brackets, or a host name.
+func ValidateHost(host string) error {
+
+	if host == std.Empty {
+		return nil
+	}
+
+	if net.ParseIP(trimBrackets(host)) != nil {
+		return nil
+	}
+
+	if len(host) > 253 {
+		return fmt.Errorf("host '%s' is too long", host)
+	}
+
+	for _, label := range strings.Split(host, std.Dot) {
+		if !validLabel(label) {
+			return fmt.Errorf("host '%s' is neither an IP address nor a host name", host)
+		}
+	}
+
+	return nil
+
+}
+
+// ValidatePort
+// Accepts ports from 0, an ephemeral port, to 65535.
+func ValidatePort(port int) error {
+	if port < 0 || port > 65535 {
+		return fmt.Errorf("port %d is out of range 0-65535", port)
+	}
+	return nil
+}
+
+// SplitAddress
+// Splits the address into the host and the port, IPv6 hosts are given in brackets, e.g. '[::1]:8080'.
+// An address without a port is returned as the host with the port of -1.
+func SplitAddress(address string) (string, int, error) {
+
+	host, rawPort, err := net.SplitHostPort(address)
+	if err != nil {
+		// The address has no port, an IPv6 address is accepted even without brackets.
+		if ValidateHost(address) == nil {
+			return trimBrackets(address), -1, nil
+		}
+		return std.Empty, 0, fmt.Errorf("%w: address '%s': %w", ErrServerSettings, address, err)
+	}
+
+	port, err := strconv.Atoi(rawPort)
+	if err != nil {
+		return std.Empty, 0, fmt.Errorf("%w: address '%s': port '%s' is not a number", ErrServerSettings, address, rawPort)
+	}
+
+	if err := ValidatePort(port); err != nil {
+		return std.Empty, 0, fmt.Errorf("%w: address '%s': %w", ErrServerSettings, address, err)
+	}
+
+	if err := ValidateHost(host); err != nil {
+		return std.Empty, 0, fmt.Errorf("%w: address '%s': %w", ErrServerSettings, address, err)
+	}
+
+	return host, port, nil
+
+}
+
+func parseClientAuth(raw string) (tls.ClientAuthType, error) {
+	switch strings.ToLower(raw) {
+	case ClientAuthNone:
+		return tls.NoClientCert, nil
+	case ClientAuthRequest:
+		return tls.RequestClientCert, nil
+	case ClientAuthRequire:
+		return tls.RequireAnyClientCert, nil
+	case ClientAuthVerifyIfGiven:
+		return tls.VerifyClientCertIfGiven, nil
+	case ClientAuthRequireAndVerify:
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("unknown client auth '%s'", raw)
+	}
+}
+
+func parsePreset(raw string) (TLSPreset, error) {
+	switch strings.ToLower(raw) {
+	case TLSPresetNameIntermediate:
+		return TLSPresetIntermediate, nil
+	case TLSPresetNameModern:
+		return TLSPresetModern, nil
+	default:
+		return TLSPresetIntermediate, fmt.Errorf("unknown preset '%s'", raw)
+	}
+}
+
+func validLabel(label string) bool {
+
+	if len(label) == 0 || len(label) > 63 {
+		return false
+	}
+
+	if label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+
+	for _, char := range label {
+		switch {
+		case char >= 'a' && char <= 'z':
+		case char >= 'A' && char <= 'Z':
+		case char >= '0' && char <= '9':
+		case char == '-' || char == '_':
+		default:
+			return false
+		}
+	}
+
+	return true
+
+}
+
+func trimBrackets(host string) string {
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		return host[1 : len(host)-1]
+	}
+	return host
+}
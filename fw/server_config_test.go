@@ -0,0 +1,192 @@
+package fw
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/evgenivanovi/gpl/cfg"
+)
+
+func TestServerSettingsFromConfig(t *testing.T) {
+
+	// given
+	t.Setenv("SERVER_HOST", "::1")
+	t.Setenv("SERVER_HTTP_ENABLED", "true")
+	t.Setenv("SERVER_HTTP_PORT", "8080")
+	t.Setenv("SERVER_GRPC_ENABLED", "true")
+	t.Setenv("SERVER_GRPC_PORT", "9000")
+
+	// when
+	settings, err := ServerSettingsFromConfig(testFlags(), cfg.WithBindRegistry(cfg.NewRegistry()))
+
+	// then
+	if err != nil {
+		t.Fatalf("ServerSettingsFromConfig() unexpected error: %v", err)
+	}
+
+	if !settings.HttpEnabled() || settings.HttpsEnabled() || !settings.GrpcEnabled() {
+		t.Errorf("ServerSettingsFromConfig() enabled http, https, grpc = %t, %t, %t, want true, false, true",
+			settings.HttpEnabled(), settings.HttpsEnabled(), settings.GrpcEnabled())
+	}
+
+	if settings.HttpAddress() != "[::1]:8080" {
+		t.Errorf("HttpAddress() = '%s', want '%s'", settings.HttpAddress(), "[::1]:8080")
+	}
+
+	if settings.GrpcAddress() != "[::1]:9000" {
+		t.Errorf("GrpcAddress() = '%s', want '%s'", settings.GrpcAddress(), "[::1]:9000")
+	}
+
+	if settings.HttpsAddress() != "[::1]:443" {
+		t.Errorf("HttpsAddress() = '%s', want the default port '%s'", settings.HttpsAddress(), "[::1]:443")
+	}
+
+}
+
+func TestServerSettingsFromConfig_Flags(t *testing.T) {
+
+	// given
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	DefineServerFlags(cfg.WithFlagSet(set))
+
+	set.String("app-flag", "", "flag of the application defined after the server flags")
+
+	// when
+	err := set.Parse([]string{"-server-http-enabled", "-server-http-port", "8081", "-app-flag", "on"})
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	first, firstErr := ServerSettingsFromConfig(cfg.WithFlagSet(set), cfg.WithBindRegistry(cfg.NewRegistry()))
+	second, secondErr := ServerSettingsFromConfig(cfg.WithFlagSet(set), cfg.WithBindRegistry(cfg.NewRegistry()))
+
+	// then
+	if firstErr != nil || secondErr != nil {
+		t.Fatalf("ServerSettingsFromConfig() unexpected errors: %v, %v", firstErr, secondErr)
+	}
+
+	for _, settings := range []*ServerSettings{first, second} {
+		if !settings.HttpEnabled() || settings.HttpAddress() != "localhost:8081" {
+			t.Errorf("ServerSettingsFromConfig() http = %t, '%s', want the flags",
+				settings.HttpEnabled(), settings.HttpAddress())
+		}
+	}
+
+	if flag.CommandLine.Lookup("server-host") != nil {
+		t.Error("ServerSettingsFromConfig() defined the flags in flag.CommandLine")
+	}
+
+}
+
+func TestServerSettingsFromConfig_Invalid(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		properties []string
+	}{
+		{
+			name: "invalid values",
+			env: map[string]string{
+				"SERVER_HOST":            "bad host",
+				"SERVER_HTTP_PORT":       "70000",
+				"SERVER_TLS_CLIENT_AUTH": "always",
+				"SERVER_TLS_PRESET":      "old",
+			},
+			properties: []string{"server.host", "server.http.port", "server.tls.client_auth", "server.tls.preset"},
+		},
+		{
+			name: "unparsable value",
+			env: map[string]string{
+				"SERVER_GRPC_PORT": "grpc",
+			},
+			properties: []string{"GRPCPort"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			// given
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			// when
+			settings, err := ServerSettingsFromConfig(testFlags(), cfg.WithBindRegistry(cfg.NewRegistry()))
+
+			// then
+			if settings != nil || !errors.Is(err, ErrServerSettings) {
+				t.Fatalf("ServerSettingsFromConfig() = %v, %v, want %v", settings, err, ErrServerSettings)
+			}
+
+			for _, property := range test.properties {
+				if !strings.Contains(err.Error(), property) {
+					t.Errorf("ServerSettingsFromConfig() error = '%v', want '%s' reported", err, property)
+				}
+			}
+
+		})
+	}
+
+}
+
+func TestSplitAddress(t *testing.T) {
+
+	tests := []struct {
+		address string
+		host    string
+		port    int
+		invalid bool
+	}{
+		{address: "localhost:8080", host: "localhost", port: 8080},
+		{address: "[::1]:8080", host: "::1", port: 8080},
+		{address: "::1", host: "::1", port: -1},
+		{address: "[fe80::1]", host: "fe80::1", port: -1},
+		{address: "example.com", host: "example.com", port: -1},
+		{address: ":9090", host: "", port: 9090},
+		{address: "localhost:http", invalid: true},
+		{address: "localhost:65536", invalid: true},
+		{address: "bad host:80", invalid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.address, func(t *testing.T) {
+
+			// when
+			host, port, err := SplitAddress(test.address)
+
+			// then
+			if test.invalid {
+				if !errors.Is(err, ErrServerSettings) {
+					t.Errorf("SplitAddress() error = %v, want %v", err, ErrServerSettings)
+				}
+				return
+			}
+
+			if err != nil || host != test.host || port != test.port {
+				t.Errorf("SplitAddress() = '%s', %d, %v, want '%s', %d", host, port, err, test.host, test.port)
+			}
+
+		})
+	}
+
+}
+
+func TestWithHttpAddress_IPv6(t *testing.T) {
+
+	// when
+	settings := NewServerSettings(WithHttpAddress("[::1]:8080"))
+
+	// then
+	if settings.HttpAddress() != "[::1]:8080" {
+		t.Errorf("HttpAddress() = '%s', want '%s'", settings.HttpAddress(), "[::1]:8080")
+	}
+
+}
+
+func testFlags() cfg.ArgSourceOp {
+	return cfg.WithFlagSet(flag.NewFlagSet("test", flag.ContinueOnError))
+}
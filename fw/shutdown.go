@@ -0,0 +1,157 @@
+package fw
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"time"
+)
+
+const DefaultShutdownTimeout = 10 * time.Second
+
+// ShutdownSettings
+// Controls the graceful shutdown of RunServer, which runs in phases:
+// readiness is reported as not ready, the drain delay passes, the servers are stopped
+// and finally the application is closed. Every phase is limited by its own timeout,
+// if set, and all of them together by the total timeout.
+type ShutdownSettings struct {
+	// Timeout limits the whole shutdown.
+	Timeout time.Duration
+	// DrainDelay is the time given to load balancers to notice that the application is not ready
+	// before the servers stop accepting connections.
+	DrainDelay time.Duration
+	// ServersTimeout limits the graceful stop of the servers,
+	// gRPC servers are stopped forcefully when it expires.
+	ServersTimeout time.Duration
+	// CloseTimeout limits stopping the components and running the close tasks.
+	CloseTimeout time.Duration
+	// Signals trigger the shutdown, none means only Application.Shutdown does.
+	Signals []os.Signal
+}
+
+func defaultShutdownSettings() ShutdownSettings {
+	return ShutdownSettings{
+		Timeout: DefaultShutdownTimeout,
+		Signals: []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+	}
+}
+
+// WithShutdownTimeout
+// Limits the whole shutdown, DefaultShutdownTimeout by default.
+func WithShutdownTimeout(timeout time.Duration) ConfigurationOp {
+	return func(cfg *Configuration) {
+		cfg.Shutdown.Timeout = timeout
+	}
+}
+
+// WithDrainDelay
+// Keeps the servers running for the delay after readiness is reported as not ready.
+func WithDrainDelay(delay time.Duration) ConfigurationOp {
+	return func(cfg *Configuration) {
+		cfg.Shutdown.DrainDelay = delay
+	}
+}
+
+// WithServersShutdownTimeout
+// Limits the graceful stop of the servers, gRPC servers are stopped forcefully when it expires.
+func WithServersShutdownTimeout(timeout time.Duration) ConfigurationOp {
+	return func(cfg *Configuration) {
+		cfg.Shutdown.ServersTimeout = timeout
+	}
+}
+
+// WithCloseTimeout
+// Limits stopping the components and running the close tasks of the application.
+func WithCloseTimeout(timeout time.Duration) ConfigurationOp {
+	return func(cfg *Configuration) {
+		cfg.Shutdown.CloseTimeout = timeout
+	}
+}
+
+// WithShutdownSignals
+// Replaces the signals triggering the shutdown, SIGINT and SIGTERM by default.
+// Without signals the shutdown is triggered only by Application.Shutdown.
+func WithShutdownSignals(signals ...os.Signal) ConfigurationOp {
+	return func(cfg *Configuration) {
+		cfg.Shutdown.Signals = signals
+	}
+}
+
+// Shutdown
+// Triggers the graceful shutdown of RunServer as a signal does
+// and waits until it completes or the context is done.
+func (a *Application) Shutdown(ctx context.Context) error {
+
+	a.requestShutdown()
+
+	select {
+	case <-a.shutdownDone:
+		return a.shutdownErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+}
+
+// Started
+// Returns the channel closed when RunServer has started the components and the servers
+// and the application is reported as ready.
+func (a *Application) Started() <-chan struct{} {
+	return a.startupDone
+}
+
+// ShuttingDown
+// Returns the channel closed when the shutdown is triggered.
+func (a *Application) ShuttingDown() <-chan struct{} {
+	return a.shutdownRequested
+}
+
+func (a *Application) requestShutdown() {
+	a.shutdownMu.Lock()
+	defer a.shutdownMu.Unlock()
+	a.shutdownOnce.Do(func() {
+		close(a.shutdownRequested)
+	})
+}
+
+// markStarted
+// Reports the application as ready and started unless the shutdown has been requested,
+// the check and the readiness change are done under the lock of requestShutdown,
+// so that the readiness reset by the shutdown is not overridden.
+func (a *Application) markStarted() bool {
+
+	a.shutdownMu.Lock()
+	defer a.shutdownMu.Unlock()
+
+	select {
+	case <-a.shutdownRequested:
+		return false
+	default:
+	}
+
+	a.Health.SetReady(true)
+	a.startupOnce.Do(func() {
+		close(a.startupDone)
+	})
+
+	return true
+
+}
+
+// completeShutdown
+// Records the result of the first completed shutdown, e.g. when RunServer is called again.
+func (a *Application) completeShutdown(err error) {
+	a.shutdownDoneOnce.Do(func() {
+		a.shutdownErr = err
+		close(a.shutdownDone)
+	})
+}
+
+// phase
+// Derives the context of a shutdown phase limited by its own timeout, if set.
+func phase(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
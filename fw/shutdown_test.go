@@ -0,0 +1,164 @@
+package fw
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunServer_Shutdown(t *testing.T) {
+
+	// given
+	stopped := make(chan struct{})
+
+	app := NewApplication()
+	app.Settings = *NewServerSettings(WithHttpEnabled(), WithHost("127.0.0.1"), WithHttpPort(0))
+	app.RegisterComponent(NewComponent("worker", nil, func(context.Context) error {
+		close(stopped)
+		return nil
+	}))
+
+	cfg := NewConfiguration(WithApplication(app), WithShutdownSignals(), WithDrainDelay(10*time.Millisecond))
+
+	result := make(chan error, 1)
+	go func() {
+		result <- RunServer(cfg)
+	}()
+
+	waitReady(t, app)
+
+	// when
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := app.Shutdown(ctx)
+
+	// then
+	if err != nil {
+		t.Fatalf("Shutdown() unexpected error: %v", err)
+	}
+
+	select {
+	case <-stopped:
+	default:
+		t.Error("Shutdown() completed without stopping the components")
+	}
+
+	if err := <-result; err != nil {
+		t.Errorf("RunServer() unexpected error: %v", err)
+	}
+
+	if report := app.Health.Ready(ctx); report.Status != StatusDown {
+		t.Errorf("Ready() = '%s' after shutdown, want '%s'", report.Status, StatusDown)
+	}
+
+}
+
+func TestRunServer_CloseTimeout(t *testing.T) {
+
+	// given
+	app := NewApplication()
+	app.Settings = *NewServerSettings()
+	app.RegisterComponent(NewComponent("stuck", nil, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	cfg := NewConfiguration(WithApplication(app), WithShutdownSignals(), WithCloseTimeout(10*time.Millisecond))
+
+	result := make(chan error, 1)
+	go func() {
+		result <- RunServer(cfg)
+	}()
+
+	waitReady(t, app)
+
+	// when
+	app.requestShutdown()
+
+	// then
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("RunServer() error = '%v', want context.DeadlineExceeded", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunServer() did not return after the close timeout")
+	}
+
+}
+
+func TestRunServer_AfterShutdown(t *testing.T) {
+
+	// given
+	app := NewApplication()
+	app.Settings = *NewServerSettings(WithHttpEnabled(), WithHost("127.0.0.1"), WithHttpPort(0))
+
+	result := make(chan error, 1)
+	go func() {
+		result <- RunServer(NewConfiguration(WithApplication(app), WithShutdownSignals()))
+	}()
+
+	waitReady(t, app)
+
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() unexpected error: %v", err)
+	}
+
+	if err := <-result; err != nil {
+		t.Fatalf("RunServer() unexpected error: %v", err)
+	}
+
+	// when
+	go func() {
+		result <- RunServer(NewConfiguration(WithApplication(app), WithShutdownSignals()))
+	}()
+
+	// then
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Errorf("RunServer() unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunServer() did not return on the application already shut down")
+	}
+
+	if report := app.Health.Ready(context.Background()); report.Status != StatusDown {
+		t.Errorf("Ready() = '%s' after shutdown, want '%s'", report.Status, StatusDown)
+	}
+
+}
+
+func TestApplication_MarkStartedAfterShutdown(t *testing.T) {
+
+	// given
+	app := NewApplication()
+	app.requestShutdown()
+
+	// when
+	started := app.markStarted()
+
+	// then
+	if started {
+		t.Error("markStarted() = true after the shutdown was requested")
+	}
+
+	if report := app.Health.Ready(context.Background()); report.Status != StatusDown {
+		t.Errorf("Ready() = '%s', want '%s'", report.Status, StatusDown)
+	}
+
+}
+
+func waitReady(t *testing.T, app *Application) {
+
+	t.Helper()
+
+	select {
+	case <-app.Started():
+	case <-time.After(5 * time.Second):
+		t.Fatal("application did not become ready")
+	}
+
+}
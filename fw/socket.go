@@ -0,0 +1,324 @@
+package fw
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/evgenivanovi/gpl/std"
+	netx "github.com/evgenivanovi/gpl/stdx/net"
+	me "github.com/hashicorp/go-multierror"
+)
+
+// Names of the activated sockets assigned to the servers, see WithSocketActivation.
+const (
+	SocketHttp  = "http"
+	SocketHttps = "https"
+	SocketGrpc  = "grpc"
+	SocketAdmin = "admin"
+)
+
+const (
+	envListenPID     = "LISTEN_PID"
+	envListenFDs     = "LISTEN_FDS"
+	envListenFDNames = "LISTEN_FDNAMES"
+
+	// listenFDsStart is the first file descriptor passed by the service manager.
+	listenFDsStart = 3
+)
+
+var ErrSocketActivation = errors.New("could not use activated sockets")
+
+// WithHttpUnixSocket
+// Serves HTTP on the unix socket at the path with the file mode, e.g. 0o660,
+// a stale socket file is removed before listening.
+func WithHttpUnixSocket(path string, mode fs.FileMode) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.httpSocket = &unixSocket{path: path, mode: mode}
+	}
+}
+
+// WithHttpsUnixSocket
+// Serves HTTPS on the unix socket at the path with the file mode, see WithHttpUnixSocket.
+func WithHttpsUnixSocket(path string, mode fs.FileMode) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.httpsSocket = &unixSocket{path: path, mode: mode}
+	}
+}
+
+// WithGrpcUnixSocket
+// Serves gRPC on the unix socket at the path with the file mode, see WithHttpUnixSocket.
+// GrpcAddress reports the socket as the 'unix:' target of gRPC clients.
+func WithGrpcUnixSocket(path string, mode fs.FileMode) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.grpcSocket = &unixSocket{path: path, mode: mode}
+	}
+}
+
+// WithAdminUnixSocket
+// Serves the admin endpoints on the unix socket at the path with the file mode, see WithHttpUnixSocket.
+func WithAdminUnixSocket(path string, mode fs.FileMode) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.adminSocket = &unixSocket{path: path, mode: mode}
+	}
+}
+
+// WithSocketActivation
+// Serves on the sockets passed by the service manager, e.g. systemd, through LISTEN_FDS.
+// The sockets named SocketHttp, SocketHttps, SocketGrpc and SocketAdmin by LISTEN_FDNAMES
+// are assigned to the servers of the same name. Unnamed sockets are assigned in the order
+// HTTP, HTTPS, gRPC, admin to the enabled servers without a listener.
+// The servers without an activated socket listen as configured.
+func WithSocketActivation() ServerOp {
+	return func(settings *ServerSettings) {
+		settings.activation = true
+	}
+}
+
+type unixSocket struct {
+	path string
+	mode fs.FileMode
+}
+
+func (s *unixSocket) address() string {
+	return netx.Unix + std.Colon + s.path
+}
+
+func (s *unixSocket) listen() (net.Listener, error) {
+
+	if info, err := os.Lstat(s.path); err == nil && info.Mode().Type() == fs.ModeSocket {
+		if err := os.Remove(s.path); err != nil {
+			return nil, fmt.Errorf("remove stale socket '%s': %w", s.path, err)
+		}
+	}
+
+	listener, err := net.Listen(netx.Unix, s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.mode != 0 {
+		if err := os.Chmod(s.path, s.mode); err != nil {
+			_ = listener.Close()
+			return nil, fmt.Errorf("chmod socket '%s': %w", s.path, err)
+		}
+	}
+
+	return listener, nil
+
+}
+
+// ActivatedListener
+// Listener passed by the service manager with its name, see ActivatedListeners.
+type ActivatedListener struct {
+	Name     string
+	Listener net.Listener
+}
+
+var activatedListeners = sync.OnceValues(activate)
+
+// ActivatedListeners
+// Returns the listeners passed by the service manager through LISTEN_PID, LISTEN_FDS and LISTEN_FDNAMES,
+// none when the process is not socket activated. The listeners are created once per process.
+func ActivatedListeners() ([]ActivatedListener, error) {
+	return activatedListeners()
+}
+
+func activate() ([]ActivatedListener, error) {
+
+	pid, err := strconv.Atoi(os.Getenv(envListenPID))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+
+	return fileListeners(os.Getenv(envListenFDs), os.Getenv(envListenFDNames), ErrSocketActivation)
+
+}
+
+// fileListeners
+// Creates the listeners of the descriptors passed from listenFDsStart, as many as count, named by names.
+func fileListeners(rawCount string, rawNames string, failure error) ([]ActivatedListener, error) {
+
+	count, err := strconv.Atoi(rawCount)
+	if err != nil || count < 0 {
+		return nil, fmt.Errorf("%w: descriptor count '%s'", failure, rawCount)
+	}
+
+	names := strings.Split(rawNames, std.Colon)
+	listeners := make([]ActivatedListener, 0, count)
+
+	for index := 0; index < count; index++ {
+
+		name := std.Empty
+		if index < len(names) {
+			name = names[index]
+		}
+
+		file := os.NewFile(uintptr(listenFDsStart+index), name)
+		listener, err := net.FileListener(file)
+		_ = file.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: descriptor %d: %w", failure, listenFDsStart+index, err)
+		}
+
+		listeners = append(listeners, ActivatedListener{Name: name, Listener: listener})
+
+	}
+
+	return listeners, nil
+
+}
+
+type listenerSlot struct {
+	name     string
+	enabled  bool
+	port     int
+	listener *net.Listener
+	socket   *unixSocket
+}
+
+func (ss *ServerSettings) slots() []listenerSlot {
+	return []listenerSlot{
+		{SocketHttp, ss.httpEnabled, ss.httpPort, &ss.httpListener, ss.httpSocket},
+		{SocketHttps, ss.httpsEnabled, ss.httpsPort, &ss.httpsListener, ss.httpsSocket},
+		{SocketGrpc, ss.grpcEnabled && !ss.multiplexed, ss.grpcPort, &ss.grpcListener, ss.grpcSocket},
+		{SocketAdmin, ss.adminEnabled, ss.adminPort, &ss.adminListener, ss.adminSocket},
+	}
+}
+
+// listen
+// Creates the listeners of the enabled servers: the listeners provided with the options are kept,
+// then the listeners inherited on restart, see Application.Restart, and the activated sockets are used,
+// and the rest listen on their unix socket or TCP address.
+// The inherited and activated listeners no server uses are closed, and on failure so are the assigned ones.
+func (ss *ServerSettings) listen() (err error) {
+
+	slots := ss.slots()
+	assigned := make([]*net.Listener, 0)
+	unused := make([]net.Listener, 0)
+
+	defer func() {
+		closeListeners(unused)
+		ss.listened = assigned
+		if err != nil {
+			ss.closeListeners()
+		}
+	}()
+
+	listeners, err := InheritedListeners()
+	if err != nil {
+		return err
+	}
+
+	if ss.activation {
+		activated, err := ActivatedListeners()
+		if err != nil {
+			for _, inherited := range listeners {
+				unused = append(unused, inherited.Listener)
+			}
+			return err
+		}
+		listeners = append(listeners, activated...)
+	}
+
+	unnamed := make([]net.Listener, 0)
+	for _, activated := range listeners {
+
+		slot, named := findSlot(slots, activated.Name)
+		switch {
+		case !named:
+			unnamed = append(unnamed, activated.Listener)
+		case slot.enabled && *slot.listener == nil:
+			*slot.listener = activated.Listener
+			assigned = append(assigned, slot.listener)
+		default:
+			unused = append(unused, activated.Listener)
+		}
+
+	}
+
+	for _, slot := range slots {
+		if len(unnamed) == 0 {
+			break
+		}
+		if slot.enabled && *slot.listener == nil {
+			*slot.listener = unnamed[0]
+			assigned = append(assigned, slot.listener)
+			unnamed = unnamed[1:]
+		}
+	}
+	unused = append(unused, unnamed...)
+
+	errs := newErrors()
+	for _, slot := range slots {
+
+		if !slot.enabled || *slot.listener != nil {
+			continue
+		}
+
+		var listener net.Listener
+		var err error
+
+		if slot.socket != nil {
+			listener, err = slot.socket.listen()
+		} else {
+			listener, err = net.Listen(netx.TCP, net.JoinHostPort(ss.host, strconv.Itoa(slot.port)))
+		}
+
+		if err != nil {
+			errs = me.Append(errs, fmt.Errorf("listen %s: %w", slot.name, err))
+			continue
+		}
+
+		*slot.listener = listener
+		assigned = append(assigned, slot.listener)
+
+	}
+
+	return errs.ErrorOrNil()
+
+}
+
+// closeListeners
+// Closes the listeners created or assigned by listen, e.g. when the servers do not start to close them,
+// closing a listener its server has closed already has no effect.
+func (ss *ServerSettings) closeListeners() {
+	for _, listener := range ss.listened {
+		if *listener != nil {
+			_ = (*listener).Close()
+			*listener = nil
+		}
+	}
+	ss.listened = nil
+}
+
+func closeListeners(listeners []net.Listener) {
+	for _, listener := range listeners {
+		_ = listener.Close()
+	}
+}
+
+func findSlot(slots []listenerSlot, name string) (listenerSlot, bool) {
+	for _, slot := range slots {
+		if slot.name == name {
+			return slot, true
+		}
+	}
+	return listenerSlot{}, false
+}
+
+// listenerAddress
+// Returns the address of the listener, the address of a unix socket is prefixed by 'unix:'.
+func listenerAddress(listener net.Listener) string {
+	address := listener.Addr()
+	if strings.HasPrefix(address.Network(), netx.Unix) {
+		return netx.Unix + std.Colon + address.String()
+	}
+	return address.String()
+}
@@ -0,0 +1,219 @@
+package fw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const activationHelperEnv = "FW_TEST_ACTIVATION_HELPER"
+
+func TestServerSettings_ListenFailure(t *testing.T) {
+
+	// given
+	httpSocket := filepath.Join(t.TempDir(), "http.sock")
+
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer busy.Close()
+
+	settings := NewServerSettings(
+		WithHost("127.0.0.1"),
+		WithHttpEnabled(),
+		WithHttpUnixSocket(httpSocket, 0o600),
+		WithHttpsEnabled(),
+		WithHttpsPort(busy.Addr().(*net.TCPAddr).Port),
+	)
+
+	// when
+	err = settings.listen()
+
+	// then
+	if err == nil {
+		t.Fatal("listen() expected error, got nil")
+	}
+
+	if settings.httpListener != nil {
+		t.Error("listen() kept the listener of the http server")
+	}
+
+	if _, err := os.Stat(httpSocket); !os.IsNotExist(err) {
+		t.Errorf("socket of the http server is not closed, stat error = %v", err)
+	}
+
+}
+
+func TestRunServer_ClosesListenersOnFailure(t *testing.T) {
+
+	// given
+	adminSocket := filepath.Join(t.TempDir(), "admin.sock")
+
+	app := NewApplication()
+	app.Settings = *NewServerSettings(
+		WithGrpcEnabled(),
+		WithMultiplexingEnabled(),
+		WithAdminEnabled(),
+		WithAdminUnixSocket(adminSocket, 0o600),
+	)
+
+	// when
+	err := RunServer(NewConfiguration(WithApplication(app), WithShutdownSignals()))
+
+	// then
+	if !errors.Is(err, ErrMultiplexing) {
+		t.Fatalf("RunServer() error = '%v', want '%v'", err, ErrMultiplexing)
+	}
+
+	if _, err := os.Stat(adminSocket); !os.IsNotExist(err) {
+		t.Errorf("socket of the admin server is not closed, stat error = %v", err)
+	}
+
+}
+
+func TestRunServer_UnixSockets(t *testing.T) {
+
+	// given
+	dir := t.TempDir()
+	httpSocket := filepath.Join(dir, "http.sock")
+	grpcSocket := filepath.Join(dir, "grpc.sock")
+
+	// A stale socket file left by a previous process.
+	stale, err := net.Listen("unix", httpSocket)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	app := NewApplication()
+	app.Settings = *NewServerSettings(
+		WithHttpEnabled(),
+		WithHttpUnixSocket(httpSocket, 0o600),
+		WithGrpcEnabled(),
+		WithGrpcUnixSocket(grpcSocket, 0o660),
+	)
+
+	cfg := NewConfiguration(WithApplication(app), WithShutdownSignals(), WithHealthEndpoints(true))
+
+	result := make(chan error, 1)
+	go func() {
+		result <- RunServer(cfg)
+	}()
+
+	waitReady(t, app)
+
+	t.Cleanup(func() {
+		_ = app.Shutdown(context.Background())
+		<-result
+	})
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", httpSocket)
+		},
+	}}
+
+	conn, err := grpc.NewClient(app.Settings.GrpcAddress(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial grpc: %v", err)
+	}
+	defer conn.Close()
+
+	// when
+	response, err := client.Get("http://unix" + HealthzPath)
+	if err != nil {
+		t.Fatalf("GET %s unexpected error: %v", HealthzPath, err)
+	}
+	_ = response.Body.Close()
+
+	health, err := grpc_health_v1.NewHealthClient(conn).
+		Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+	info, statErr := os.Stat(grpcSocket)
+
+	// then
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("GET %s = %d, want %d", HealthzPath, response.StatusCode, http.StatusOK)
+	}
+
+	if err != nil || health.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Errorf("Check() = %v, %v, want SERVING", health, err)
+	}
+
+	if statErr != nil || info.Mode().Perm() != 0o660 {
+		t.Errorf("socket mode = %v, %v, want %v", info.Mode().Perm(), statErr, os.FileMode(0o660))
+	}
+
+	if app.Settings.HttpAddress() != "unix:"+httpSocket {
+		t.Errorf("HttpAddress() = '%s', want '%s'", app.Settings.HttpAddress(), "unix:"+httpSocket)
+	}
+
+}
+
+func TestActivatedListeners(t *testing.T) {
+
+	if os.Getenv(activationHelperEnv) != "" {
+		activationHelper()
+		return
+	}
+
+	// given
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	file, err := listener.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("file: %v", err)
+	}
+	defer file.Close()
+
+	// The helper process receives the socket as descriptor 3, as if passed by systemd.
+	cmd := exec.Command(os.Args[0], "-test.run=^TestActivatedListeners$")
+	cmd.Env = append(os.Environ(), activationHelperEnv+"=1", "LISTEN_FDS=1", "LISTEN_FDNAMES="+SocketGrpc)
+	cmd.ExtraFiles = []*os.File{file}
+
+	// when
+	output, err := cmd.CombinedOutput()
+
+	// then
+	if err != nil {
+		t.Fatalf("helper process failed: %v\n%s", err, output)
+	}
+
+	want := fmt.Sprintf("activated %s %s", SocketGrpc, listener.Addr())
+	if !strings.Contains(string(output), want) {
+		t.Errorf("helper process output = '%s', want '%s'", output, want)
+	}
+
+}
+
+func activationHelper() {
+
+	// The service manager sets the pid of the activated process.
+	_ = os.Setenv("LISTEN_PID", fmt.Sprint(os.Getpid()))
+
+	settings := NewServerSettings(WithGrpcEnabled(), WithSocketActivation())
+	if err := settings.listen(); err != nil {
+		fmt.Println("error", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("activated", SocketGrpc, settings.GrpcAddress())
+
+}
@@ -2,40 +2,139 @@ package fw
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"slices"
 
 	"github.com/gookit/goutil/strutil"
 )
 
+// TLSPreset
+// Selects the protocol versions and cipher suites of the servers.
+type TLSPreset int
+
+const (
+	// TLSPresetIntermediate accepts TLS 1.2 with forward secret AEAD cipher suites and TLS 1.3.
+	TLSPresetIntermediate TLSPreset = iota
+	// TLSPresetModern accepts TLS 1.3 only.
+	TLSPresetModern
+)
+
+var intermediateCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// WithGrpcTLS
+// Serves gRPC with the TLS settings of the HTTPS server.
+// It has no effect in the single port mode, where gRPC is secured by the HTTPS server itself.
+func WithGrpcTLS(enabled bool) ServerOp {
+	return func(settings *ServerSettings) {
+		settings.grpcTLS = enabled
+	}
+}
+
+func WithGrpcTLSEnabled() ServerOp {
+	return func(settings *ServerSettings) {
+		settings.grpcTLS = true
+	}
+}
+
+func (ss ServerSettings) GrpcTLSEnabled() bool {
+	return ss.grpcTLS && !ss.multiplexed
+}
+
 type TLSOp func(*TLS)
 
 func WithConfig(config *tls.Config) TLSOp {
-	return func(tls *TLS) {
-		if tls != nil {
-			tls.config = config
+	return func(t *TLS) {
+		if t != nil {
+			t.config = config
 		}
 	}
 }
 
 func WithCert(cert string) TLSOp {
-	return func(tls *TLS) {
-		if tls != nil {
-			tls.cert = cert
+	return func(t *TLS) {
+		if t != nil {
+			t.cert = cert
 		}
 	}
 }
 
 func WithKey(key string) TLSOp {
-	return func(tls *TLS) {
-		if tls != nil {
-			tls.key = key
+	return func(t *TLS) {
+		if t != nil {
+			t.key = key
 		}
 	}
 }
 
 func WithCertKey(cert, key string) TLSOp {
-	return func(tls *TLS) {
-		tls.cert = cert
-		tls.key = key
+	return func(t *TLS) {
+		t.cert = cert
+		t.key = key
+	}
+}
+
+// WithPreset
+// Applies the protocol versions and cipher suites of the preset.
+func WithPreset(preset TLSPreset) TLSOp {
+	return func(t *TLS) {
+		switch preset {
+		case TLSPresetModern:
+			t.minVersion = tls.VersionTLS13
+			t.cipherSuites = nil
+		default:
+			t.minVersion = tls.VersionTLS12
+			t.cipherSuites = slices.Clone(intermediateCipherSuites)
+		}
+	}
+}
+
+// WithMinVersion
+// Sets the minimum accepted protocol version, TLS 1.2 by default.
+func WithMinVersion(version uint16) TLSOp {
+	return func(t *TLS) {
+		t.minVersion = version
+	}
+}
+
+// WithCipherSuites
+// Sets the accepted cipher suites of TLS 1.2, TLS 1.3 suites are not configurable.
+func WithCipherSuites(suites ...uint16) TLSOp {
+	return func(t *TLS) {
+		t.cipherSuites = suites
+	}
+}
+
+// WithClientCA
+// Verifies client certificates against the CA bundles of the files, enabling mutual TLS.
+// Client certificates are required unless another policy is set with WithClientAuth.
+func WithClientCA(files ...string) TLSOp {
+	return func(t *TLS) {
+		t.clientCAs = append(t.clientCAs, files...)
+	}
+}
+
+// WithClientAuth
+// Sets the policy for client certificates.
+func WithClientAuth(auth tls.ClientAuthType) TLSOp {
+	return func(t *TLS) {
+		t.clientAuth = &auth
+	}
+}
+
+// WithCertManagerOps
+// Configures the CertManager reloading the cert and key files.
+func WithCertManagerOps(ops ...CertManagerOp) TLSOp {
+	return func(t *TLS) {
+		t.managerOps = append(t.managerOps, ops...)
 	}
 }
 
@@ -43,31 +142,37 @@ type TLS struct {
 	config *tls.Config
 	cert   string
 	key    string
+
+	minVersion   uint16
+	cipherSuites []uint16
+	clientCAs    []string
+	clientAuth   *tls.ClientAuthType
+	managerOps   []CertManagerOp
 }
 
-func (tls *TLS) Enabled() bool {
-	return tls.EnabledAutoTLS() || tls.EnabledNonAutoTLS()
+func (t *TLS) Enabled() bool {
+	return t.EnabledAutoTLS() || t.EnabledNonAutoTLS()
 }
 
-func (tls *TLS) Disabled() bool {
-	return !tls.Enabled()
+func (t *TLS) Disabled() bool {
+	return !t.Enabled()
 }
 
 // EnabledAutoTLS
 // Filenames containing a certificate and matching private key for the
 // server must be provided if neither the Server's TLSConfig.Certificates
 // nor TLSConfig.GetCertificate are populated.
-func (tls *TLS) EnabledAutoTLS() bool {
+func (t *TLS) EnabledAutoTLS() bool {
 
-	if tls == nil || tls.config == nil {
+	if t == nil || t.config == nil {
 		return false
 	}
 
-	if len(tls.config.Certificates) == 0 || tls.config.GetCertificate == nil {
+	if len(t.config.Certificates) == 0 || t.config.GetCertificate == nil {
 		return false
 	}
 
-	return strutil.IsBlank(tls.cert) && strutil.IsBlank(tls.key)
+	return strutil.IsBlank(t.cert) && strutil.IsBlank(t.key)
 
 }
 
@@ -75,17 +180,92 @@ func (tls *TLS) EnabledAutoTLS() bool {
 // Filenames containing a certificate and matching private key for the
 // server must be provided if neither the Server's TLSConfig.Certificates
 // nor TLSConfig.GetCertificate are populated.
-func (tls *TLS) EnabledNonAutoTLS() bool {
+func (t *TLS) EnabledNonAutoTLS() bool {
 
-	if tls == nil || tls.config == nil {
+	if t == nil || t.config == nil {
 		return false
 	}
 
-	if len(tls.config.Certificates) != 0 || tls.config.GetCertificate != nil {
+	if len(t.config.Certificates) != 0 || t.config.GetCertificate != nil {
 		return false
 	}
 
-	return strutil.IsNotBlank(tls.cert) && strutil.IsNotBlank(tls.key)
+	return strutil.IsNotBlank(t.cert) && strutil.IsNotBlank(t.key)
+
+}
+
+// Config
+// Builds the configuration of the servers from the provided tls.Config and the options.
+// The certificate of the cert and key files is served by a CertManager,
+// so that it is reloaded when the files change.
+func (t *TLS) Config() (*tls.Config, error) {
+
+	config := &tls.Config{}
+	if t.config != nil {
+		config = t.config.Clone()
+	}
+
+	if t.minVersion != 0 {
+		config.MinVersion = t.minVersion
+	}
+
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+
+	// Every configuration gets its own copy, so that changing one does not affect the others.
+	if t.cipherSuites != nil {
+		config.CipherSuites = slices.Clone(t.cipherSuites)
+	}
+
+	if strutil.IsNotBlank(t.cert) || strutil.IsNotBlank(t.key) {
+		manager, err := NewCertManager(t.cert, t.key, t.managerOps...)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = nil
+		config.GetCertificate = manager.GetCertificate
+	}
+
+	if len(config.Certificates) == 0 && config.GetCertificate == nil && config.GetConfigForClient == nil {
+		return nil, fmt.Errorf("%w: neither certificate files nor a certificate are provided", ErrCertificate)
+	}
+
+	if len(t.clientCAs) != 0 {
+		pool, err := certPool(t.clientCAs)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	if t.clientAuth != nil {
+		config.ClientAuth = *t.clientAuth
+	}
+
+	return config, nil
+
+}
+
+func certPool(files []string) (*x509.CertPool, error) {
+
+	pool := x509.NewCertPool()
+
+	for _, file := range files {
+
+		bundle, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("%w: client CA: %w", ErrCertificate, err)
+		}
+
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("%w: client CA '%s': no PEM certificates", ErrCertificate, file)
+		}
+
+	}
+
+	return pool, nil
 
 }
 
@@ -0,0 +1,316 @@
+package fw
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issue
+// Writes a certificate signed by the authority, or a self-signed one when it is nil, and its key.
+func issue(t *testing.T, ca *authority, name string, serial int64) (string, string, *authority) {
+
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	parent, signer := template, key
+	if ca == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		parent, signer = ca.cert, ca.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+
+	write(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	write(t, keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+
+	cert, _ := x509.ParseCertificate(der)
+	return certFile, keyFile, &authority{cert: cert, key: key}
+
+}
+
+func write(t *testing.T, file string, content []byte) {
+	t.Helper()
+	if err := os.WriteFile(file, content, 0o600); err != nil {
+		t.Fatalf("write '%s': %v", file, err)
+	}
+}
+
+func serial(t *testing.T, certificate *tls.Certificate) int64 {
+	t.Helper()
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return leaf.SerialNumber.Int64()
+}
+
+func TestCertManager_Reload(t *testing.T) {
+
+	// given
+	cert, key, _ := issue(t, nil, "localhost", 1)
+	renewedCert, renewedKey, _ := issue(t, nil, "localhost", 2)
+
+	manager, err := NewCertManager(cert, key, WithCertCheckInterval(0))
+	if err != nil {
+		t.Fatalf("NewCertManager() unexpected error: %v", err)
+	}
+
+	// when
+	before, _ := manager.GetCertificate(nil)
+
+	renew := func(from, to string) {
+		content, _ := os.ReadFile(from)
+		write(t, to, content)
+		later := time.Now().Add(time.Minute)
+		_ = os.Chtimes(to, later, later)
+	}
+	renew(renewedCert, cert)
+	renew(renewedKey, key)
+
+	after, _ := manager.GetCertificate(nil)
+
+	write(t, cert, []byte("broken"))
+	broken, _ := manager.GetCertificate(nil)
+
+	// then
+	if serial(t, before) != 1 {
+		t.Errorf("GetCertificate() serial = %d, want 1", serial(t, before))
+	}
+
+	if serial(t, after) != 2 {
+		t.Errorf("GetCertificate() serial = %d after renewal, want 2", serial(t, after))
+	}
+
+	if serial(t, broken) != 2 {
+		t.Errorf("GetCertificate() serial = %d after a broken renewal, want the previous 2", serial(t, broken))
+	}
+
+}
+
+func TestTLS_Config(t *testing.T) {
+
+	cert, key, _ := issue(t, nil, "localhost", 1)
+	ca, _, _ := issue(t, nil, "ca", 2)
+
+	tests := []struct {
+		name       string
+		ops        []TLSOp
+		minVersion uint16
+		clientAuth tls.ClientAuthType
+		err        error
+	}{
+		{
+			name:       "defaults",
+			ops:        []TLSOp{WithCertKey(cert, key)},
+			minVersion: tls.VersionTLS12,
+			clientAuth: tls.NoClientCert,
+		},
+		{
+			name:       "modern",
+			ops:        []TLSOp{WithCertKey(cert, key), WithPreset(TLSPresetModern)},
+			minVersion: tls.VersionTLS13,
+			clientAuth: tls.NoClientCert,
+		},
+		{
+			name:       "mutual",
+			ops:        []TLSOp{WithCertKey(cert, key), WithClientCA(ca)},
+			minVersion: tls.VersionTLS12,
+			clientAuth: tls.RequireAndVerifyClientCert,
+		},
+		{
+			name:       "optional client certificate",
+			ops:        []TLSOp{WithCertKey(cert, key), WithClientCA(ca), WithClientAuth(tls.VerifyClientCertIfGiven)},
+			minVersion: tls.VersionTLS12,
+			clientAuth: tls.VerifyClientCertIfGiven,
+		},
+		{
+			name: "missing certificate",
+			err:  ErrCertificate,
+		},
+		{
+			name: "invalid client CA",
+			ops:  []TLSOp{WithCertKey(cert, key), WithClientCA(key)},
+			err:  ErrCertificate,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			// when
+			config, err := NewTLS(test.ops...).Config()
+
+			// then
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Errorf("Config() error = %v, want %v", err, test.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Config() unexpected error: %v", err)
+			}
+
+			if config.MinVersion != test.minVersion {
+				t.Errorf("MinVersion = %x, want %x", config.MinVersion, test.minVersion)
+			}
+
+			if config.ClientAuth != test.clientAuth {
+				t.Errorf("ClientAuth = %s, want %s", config.ClientAuth, test.clientAuth)
+			}
+
+			if config.GetCertificate == nil {
+				t.Error("GetCertificate is nil, want the certificate manager")
+			}
+
+		})
+	}
+
+}
+
+func TestTLS_ConfigCipherSuitesCopied(t *testing.T) {
+
+	// given
+	cert, key, _ := issue(t, nil, "localhost", 1)
+	settings := NewTLS(WithCertKey(cert, key), WithPreset(TLSPresetIntermediate))
+
+	first, err := settings.Config()
+	if err != nil {
+		t.Fatalf("Config() unexpected error: %v", err)
+	}
+
+	// when
+	first.CipherSuites[0] = 0
+
+	second, err := settings.Config()
+
+	// then
+	if err != nil {
+		t.Fatalf("Config() unexpected error: %v", err)
+	}
+
+	if second.CipherSuites[0] != intermediateCipherSuites[0] || intermediateCipherSuites[0] == 0 {
+		t.Errorf("CipherSuites = %v, changed through another configuration", second.CipherSuites)
+	}
+
+}
+
+func TestRunServer_MutualTLS(t *testing.T) {
+
+	// given
+	caCert, _, ca := issue(t, nil, "ca", 1)
+	cert, key, _ := issue(t, ca, "localhost", 2)
+	clientCert, clientKey, _ := issue(t, ca, "client", 3)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	app := NewApplication()
+	app.Settings = *NewServerSettings(
+		WithHttpsEnabled(),
+		WithHttpsListener(listener),
+		WithTLS(func() *TLS {
+			return NewTLS(WithCertKey(cert, key), WithClientCA(caCert))
+		}),
+	)
+
+	cfg := NewConfiguration(WithApplication(app), WithShutdownSignals(), WithHealthEndpoints(true))
+
+	result := make(chan error, 1)
+	go func() {
+		result <- RunServer(cfg)
+	}()
+
+	waitReady(t, app)
+
+	t.Cleanup(func() {
+		_ = app.Shutdown(context.Background())
+		<-result
+	})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	certificate, err := tls.LoadX509KeyPair(clientCert, clientKey)
+	if err != nil {
+		t.Fatalf("load client certificate: %v", err)
+	}
+
+	client := func(certificates ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certificates},
+		}}
+	}
+
+	url := "https://" + app.Settings.HttpsAddress() + HealthzPath
+
+	// when
+	response, err := client(certificate).Get(url)
+	_, anonymous := client().Get(url)
+
+	// then
+	if err != nil {
+		t.Fatalf("GET with a client certificate unexpected error: %v", err)
+	}
+	_ = response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("GET with a client certificate = %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	if anonymous == nil {
+		t.Error("GET without a client certificate succeeded, want a handshake error")
+	}
+
+}
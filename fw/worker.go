@@ -0,0 +1,300 @@
+package fw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/evgenivanovi/gpl/std"
+	slogx "github.com/evgenivanovi/gpl/stdx/log/slog"
+	"github.com/evgenivanovi/gpl/stdx/xsync"
+)
+
+const (
+	DefaultWorkerMinBackoff = 100 * time.Millisecond
+	DefaultWorkerMaxBackoff = 30 * time.Second
+)
+
+// WorkerCheckPrefix
+// Prefixes the names of the health checks of the workers.
+const WorkerCheckPrefix = "worker."
+
+var ErrWorkerDown = errors.New("worker is not running")
+
+// WorkerState
+// Stage of the lifetime of a worker.
+type WorkerState string
+
+const (
+	WorkerStarting WorkerState = "starting"
+	WorkerRunning  WorkerState = "running"
+	WorkerBackoff  WorkerState = "backoff"
+	WorkerFailed   WorkerState = "failed"
+	WorkerStopped  WorkerState = "stopped"
+)
+
+// WorkerStatus
+// Snapshot of the state of a worker, Error is the last failure.
+type WorkerStatus struct {
+	Name     string      `json:"name"`
+	State    WorkerState `json:"state"`
+	Restarts int         `json:"restarts"`
+	Error    string      `json:"error,omitempty"`
+}
+
+type WorkerOp func(*worker)
+
+// WithBackoff
+// Delays the restarts of a failed worker from min, doubling up to max,
+// DefaultWorkerMinBackoff and DefaultWorkerMaxBackoff by default.
+// The delay is reset once the worker has run for longer than max.
+func WithBackoff(min, max time.Duration) WorkerOp {
+	return func(w *worker) {
+		w.minBackoff = min
+		w.maxBackoff = max
+	}
+}
+
+// WithMaxRestarts
+// Gives up the worker after the number of consecutive restarts, it is restarted indefinitely by default.
+func WithMaxRestarts(restarts int) WorkerOp {
+	return func(w *worker) {
+		w.maxRestarts = restarts
+	}
+}
+
+// WithWorkerCheckOps
+// Configures the health check reporting the worker, e.g. NonCritical.
+func WithWorkerCheckOps(ops ...CheckOp) WorkerOp {
+	return func(w *worker) {
+		w.checkOps = append(w.checkOps, ops...)
+	}
+}
+
+// WithWorkerComponentOps
+// Configures the component running the worker, e.g. DependsOn or WithStopTimeout limiting the drain.
+func WithWorkerComponentOps(ops ...ComponentOp) WorkerOp {
+	return func(w *worker) {
+		w.componentOps = append(w.componentOps, ops...)
+	}
+}
+
+type worker struct {
+	name string
+	run  func(ctx context.Context) error
+
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	maxRestarts  int
+	checkOps     []CheckOp
+	componentOps []ComponentOp
+
+	mu       sync.Mutex
+	state    WorkerState
+	restarts int
+	err      error
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// RegisterWorker
+// Registers the function running for the lifetime of the application.
+// The worker is started with the components and its context is cancelled when the application is closed,
+// closing waits until the worker returns or the stop timeout of its component is hit.
+//
+// A worker failing or panicking is restarted with backoff, a worker returning nil is not restarted.
+// The state of the worker is reported by Workers and by the readiness check named WorkerCheckPrefix + name.
+func (a *Application) RegisterWorker(name string, run func(ctx context.Context) error, ops ...WorkerOp) {
+
+	w := &worker{
+		name:       name,
+		run:        run,
+		minBackoff: DefaultWorkerMinBackoff,
+		maxBackoff: DefaultWorkerMaxBackoff,
+		state:      WorkerStarting,
+	}
+
+	for _, op := range ops {
+		op(w)
+	}
+
+	a.lifecycle.Lock()
+	a.workers = append(a.workers, w)
+	a.lifecycle.Unlock()
+
+	a.Health.Register(WorkerCheckPrefix+name, w.check, w.checkOps...)
+	a.RegisterComponent(NewComponent(WorkerCheckPrefix+name, a.starter(w), w.stop), w.componentOps...)
+
+}
+
+// Workers
+// Returns the states of the registered workers.
+func (a *Application) Workers() []WorkerStatus {
+
+	a.lifecycle.Lock()
+	defer a.lifecycle.Unlock()
+
+	statuses := make([]WorkerStatus, 0, len(a.workers))
+	for _, w := range a.workers {
+		statuses = append(statuses, w.status())
+	}
+
+	return statuses
+
+}
+
+func (a *Application) starter(w *worker) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+
+		// The worker outlives the start of the application.
+		ctx, cancel := context.WithCancel(WithCtx(context.WithoutCancel(ctx), a))
+
+		w.mu.Lock()
+		w.cancel = cancel
+		w.done = make(chan struct{})
+		w.mu.Unlock()
+
+		go w.supervise(ctx)
+		return nil
+
+	}
+}
+
+func (w *worker) supervise(ctx context.Context) {
+
+	defer close(w.done)
+
+	failures := 0
+
+	for {
+
+		w.update(WorkerRunning, nil)
+		began := time.Now()
+
+		err := xsync.RunErrorFunc(func() error {
+			return w.run(ctx)
+		})
+
+		// The error of a cancelled worker is the cancellation itself.
+		if ctx.Err() != nil || err == nil {
+			w.update(WorkerStopped, nil)
+			return
+		}
+
+		if time.Since(began) > w.maxBackoff {
+			failures = 0
+		}
+		failures++
+
+		if w.maxRestarts > 0 && failures > w.maxRestarts {
+			slogx.Log().Error(
+				"Worker failed",
+				slog.String("worker", w.name),
+				slog.String("error", err.Error()),
+			)
+			w.update(WorkerFailed, err)
+			return
+		}
+
+		delay := w.backoff(failures)
+
+		slogx.Log().Warn(
+			"Restarting worker",
+			slog.String("worker", w.name),
+			slog.Duration("delay", delay),
+			slog.String("error", err.Error()),
+		)
+
+		w.update(WorkerBackoff, err)
+
+		select {
+		case <-ctx.Done():
+			w.update(WorkerStopped, nil)
+			return
+		case <-time.After(delay):
+		}
+
+		w.mu.Lock()
+		w.restarts++
+		w.mu.Unlock()
+
+	}
+
+}
+
+func (w *worker) backoff(failures int) time.Duration {
+	delay := w.minBackoff
+	for i := 1; i < failures && delay < w.maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, w.maxBackoff)
+}
+
+func (w *worker) stop(ctx context.Context) error {
+
+	w.mu.Lock()
+	cancel, done := w.cancel, w.done
+	w.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("drain worker '%s': %w", w.name, ctx.Err())
+	}
+
+}
+
+func (w *worker) check(context.Context) error {
+
+	status := w.status()
+
+	switch status.State {
+	case WorkerStarting, WorkerBackoff, WorkerFailed:
+		if status.Error == std.Empty {
+			return fmt.Errorf("%w: %s", ErrWorkerDown, status.State)
+		}
+		return fmt.Errorf("%w: %s: %s", ErrWorkerDown, status.State, status.Error)
+	default:
+		return nil
+	}
+
+}
+
+func (w *worker) update(state WorkerState, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.state = state
+	if err != nil {
+		w.err = err
+	}
+}
+
+func (w *worker) status() WorkerStatus {
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	status := WorkerStatus{
+		Name:     w.name,
+		State:    w.state,
+		Restarts: w.restarts,
+	}
+
+	if w.err != nil {
+		status.Error = w.err.Error()
+	}
+
+	return status
+
+}
@@ -0,0 +1,128 @@
+package fw
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func eventually(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition was not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRegisterWorker_Restart(t *testing.T) {
+
+	// given
+	app := NewApplication()
+
+	var runs atomic.Int32
+	app.RegisterWorker(
+		"consumer",
+		func(ctx context.Context) error {
+			switch runs.Add(1) {
+			case 1:
+				return errors.New("connection lost")
+			case 2:
+				panic("boom")
+			default:
+				<-ctx.Done()
+				return ctx.Err()
+			}
+		},
+		WithBackoff(time.Millisecond, 10*time.Millisecond),
+	)
+
+	// when
+	if err := app.StartContext(context.Background()); err != nil {
+		t.Fatalf("StartContext() unexpected error: %v", err)
+	}
+
+	eventually(t, func() bool { return runs.Load() == 3 })
+	running := app.Workers()[0]
+	report := app.Health.Ready(context.Background())
+
+	err := app.CloseContext(context.Background())
+
+	// then
+	if running.State != WorkerRunning || running.Restarts != 2 {
+		t.Errorf("Workers() = %+v, want running after 2 restarts", running)
+	}
+
+	if len(report.Checks) != 1 || report.Checks[0].Status != StatusUp {
+		t.Errorf("Ready() = %+v, want the worker check up", report)
+	}
+
+	if err != nil {
+		t.Errorf("CloseContext() unexpected error: %v", err)
+	}
+
+	if stopped := app.Workers()[0]; stopped.State != WorkerStopped {
+		t.Errorf("Workers() = %+v after close, want stopped", stopped)
+	}
+
+}
+
+func TestRegisterWorker_MaxRestarts(t *testing.T) {
+
+	// given
+	app := NewApplication()
+
+	app.RegisterWorker(
+		"broken",
+		func(ctx context.Context) error { return errors.New("misconfigured") },
+		WithBackoff(time.Millisecond, time.Millisecond),
+		WithMaxRestarts(2),
+	)
+
+	// when
+	_ = app.StartContext(context.Background())
+	eventually(t, func() bool { return app.Workers()[0].State == WorkerFailed })
+
+	result, _ := app.Health.CheckOne(context.Background(), WorkerCheckPrefix+"broken")
+
+	// then
+	if status := app.Workers()[0]; status.Restarts != 2 || status.Error != "misconfigured" {
+		t.Errorf("Workers() = %+v, want failed after 2 restarts", status)
+	}
+
+	if result.Status != StatusDown {
+		t.Errorf("CheckOne() = %+v, want down", result)
+	}
+
+	_ = app.CloseContext(context.Background())
+
+}
+
+func TestRegisterWorker_DrainTimeout(t *testing.T) {
+
+	// given
+	app := NewApplication()
+	app.RegisterWorker(
+		"stubborn",
+		func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+		WithWorkerComponentOps(WithStopTimeout(10*time.Millisecond)),
+	)
+
+	_ = app.StartContext(context.Background())
+
+	// when
+	err := app.CloseContext(context.Background())
+
+	// then
+	if !errors.Is(err, ErrComponentTimeout) {
+		t.Errorf("CloseContext() error = %v, want %v", err, ErrComponentTimeout)
+	}
+
+}
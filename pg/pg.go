@@ -227,3 +227,9 @@ func NewDatasource(
 	}, nil
 
 }
+
+// Ping
+// Verifies that the database is reachable through the pool, e.g. as a readiness check.
+func (d *Datasource) Ping(ctx context.Context) error {
+	return d.Pool.Ping(ctx)
+}
@@ -2,16 +2,45 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"log/slog"
+	"math"
 	"net"
+	"net/http"
 	"strconv"
+	"sync"
+	"time"
 
+	"github.com/evgenivanovi/gpl/std"
 	netx "github.com/evgenivanovi/gpl/stdx/net"
 	grpcmw "github.com/grpc-ecosystem/go-grpc-middleware"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/stats"
 )
 
+const (
+	// DefaultMaxRecvMsgSize - limits the size of a received message, as gRPC does by default.
+	DefaultMaxRecvMsgSize = 4 << 20
+	// DefaultMaxSendMsgSize - does not limit the size of a sent message, as gRPC does by default.
+	DefaultMaxSendMsgSize = math.MaxInt32
+)
+
+// DefaultKeepalive - pings idle connections every minute and closes them when a ping is not answered in 20 seconds.
+var DefaultKeepalive = keepalive.ServerParameters{
+	Time:    time.Minute,
+	Timeout: 20 * time.Second,
+}
+
+// DefaultKeepaliveEnforcement - the policy of gRPC: allows clients to ping every 5 minutes while streams are active,
+// more frequent pings close the connection.
+var DefaultKeepaliveEnforcement = keepalive.EnforcementPolicy{
+	MinTime:             5 * time.Minute,
+	PermitWithoutStream: false,
+}
+
 type GRPCService interface {
 	RegisterService(grpc.ServiceRegistrar)
 }
@@ -19,10 +48,11 @@ type GRPCService interface {
 // GRPCServerOp - is callback function that applies an option to GrpcServer.
 type GRPCServerOp func(*GRPCServer)
 
-// WithGrpcServerConfig - adds GrpcServerConfig to GrpcServer.
+// WithGrpcServerConfig - adds GrpcServerConfig to GrpcServer,
+// its non-zero fields replace those set by the preceding options, the zero ones keep them.
 func WithGrpcServerConfig(config GRPCServerConfig) GRPCServerOp {
 	return func(server *GRPCServer) {
-		server.config = config
+		server.config = server.config.merge(config)
 	}
 }
 
@@ -77,6 +107,74 @@ func WithUnaryInterceptors(in ...grpc.UnaryServerInterceptor) GRPCServerOp {
 	}
 }
 
+// WithListener - serves GrpcServer on the provided listener instead of listening on the configured port.
+func WithListener(listener net.Listener) GRPCServerOp {
+	return func(server *GRPCServer) {
+		server.listener = listener
+	}
+}
+
+// WithCredentials - secures GrpcServer connections with the transport credentials.
+func WithCredentials(creds credentials.TransportCredentials) GRPCServerOp {
+	return func(server *GRPCServer) {
+		server.credentials = creds
+	}
+}
+
+// WithTLSConfig - secures GrpcServer connections with TLS, nil config leaves them insecure.
+func WithTLSConfig(config *tls.Config) GRPCServerOp {
+	return func(server *GRPCServer) {
+		if config != nil {
+			server.credentials = credentials.NewTLS(config)
+		}
+	}
+}
+
+// WithMaxRecvMsgSize - limits the size of a message GrpcServer receives, DefaultMaxRecvMsgSize by default.
+func WithMaxRecvMsgSize(size int) GRPCServerOp {
+	return func(server *GRPCServer) {
+		server.config.MaxRecvMsgSize = size
+	}
+}
+
+// WithMaxSendMsgSize - limits the size of a message GrpcServer sends, e.g. to DefaultMaxRecvMsgSize of the clients,
+// unlimited by default.
+func WithMaxSendMsgSize(size int) GRPCServerOp {
+	return func(server *GRPCServer) {
+		server.config.MaxSendMsgSize = size
+	}
+}
+
+// WithKeepalive - sets the keepalive of GrpcServer connections, DefaultKeepalive by default.
+func WithKeepalive(params keepalive.ServerParameters) GRPCServerOp {
+	return func(server *GRPCServer) {
+		server.config.Keepalive = params
+	}
+}
+
+// WithKeepaliveEnforcement - sets the keepalive policy GrpcServer enforces on clients,
+// DefaultKeepaliveEnforcement by default.
+func WithKeepaliveEnforcement(policy keepalive.EnforcementPolicy) GRPCServerOp {
+	return func(server *GRPCServer) {
+		server.config.KeepaliveEnforcement = policy
+	}
+}
+
+// WithStatsHandlers - add []stats.Handler to GrpcServer, e.g. for metrics and tracing.
+func WithStatsHandlers(handlers ...stats.Handler) GRPCServerOp {
+	return func(server *GRPCServer) {
+		server.statsHandlers = append(server.statsHandlers, handlers...)
+	}
+}
+
+// WithServerOptions - add []grpc.ServerOption to GrpcServer,
+// they are applied last and so override the options built from the config.
+func WithServerOptions(options ...grpc.ServerOption) GRPCServerOp {
+	return func(server *GRPCServer) {
+		server.options = append(server.options, options...)
+	}
+}
+
 // WithLogger - adds slog.Logger to GrpcServer.
 func WithLogger(log *slog.Logger) GRPCServerOp {
 	return func(server *GRPCServer) {
@@ -84,22 +182,112 @@ func WithLogger(log *slog.Logger) GRPCServerOp {
 	}
 }
 
+// GRPCServerConfig - the address and connection limits of GrpcServer,
+// zero limits and keepalive mean the defaults.
 type GRPCServerConfig struct {
+	Host string
 	Port int
+
+	MaxRecvMsgSize       int
+	MaxSendMsgSize       int
+	Keepalive            keepalive.ServerParameters
+	KeepaliveEnforcement keepalive.EnforcementPolicy
 }
 
+// NewGRPCServerConfig - creates GRPCServerConfig listening on every interface.
 func NewGRPCServerConfig(port int) *GRPCServerConfig {
 	return &GRPCServerConfig{
 		Port: port,
 	}
 }
 
+// NewGRPCServerAddressConfig - creates GRPCServerConfig listening on the host, an empty host means every interface.
+func NewGRPCServerAddressConfig(host string, port int) *GRPCServerConfig {
+	return &GRPCServerConfig{
+		Host: host,
+		Port: port,
+	}
+}
+
+func (cfg GRPCServerConfig) merge(other GRPCServerConfig) GRPCServerConfig {
+
+	if other.Host != std.Empty {
+		cfg.Host = other.Host
+	}
+
+	if other.Port != 0 {
+		cfg.Port = other.Port
+	}
+
+	if other.MaxRecvMsgSize != 0 {
+		cfg.MaxRecvMsgSize = other.MaxRecvMsgSize
+	}
+
+	if other.MaxSendMsgSize != 0 {
+		cfg.MaxSendMsgSize = other.MaxSendMsgSize
+	}
+
+	if other.Keepalive != (keepalive.ServerParameters{}) {
+		cfg.Keepalive = other.Keepalive
+	}
+
+	if other.KeepaliveEnforcement != (keepalive.EnforcementPolicy{}) {
+		cfg.KeepaliveEnforcement = other.KeepaliveEnforcement
+	}
+
+	return cfg
+
+}
+
 func (cfg GRPCServerConfig) PortString() string {
 	return strconv.Itoa(cfg.Port)
 }
 
+// Address - returns the address to listen on, IPv6 hosts are enclosed in brackets.
+func (cfg GRPCServerConfig) Address() string {
+	return net.JoinHostPort(cfg.Host, cfg.PortString())
+}
+
+// ServerOptions - returns the grpc.ServerOption of the limits and keepalive, the defaults replace zero values.
+func (cfg GRPCServerConfig) ServerOptions() []grpc.ServerOption {
+
+	recv := cfg.MaxRecvMsgSize
+	if recv <= 0 {
+		recv = DefaultMaxRecvMsgSize
+	}
+
+	send := cfg.MaxSendMsgSize
+	if send <= 0 {
+		send = DefaultMaxSendMsgSize
+	}
+
+	params := cfg.Keepalive
+	if params == (keepalive.ServerParameters{}) {
+		params = DefaultKeepalive
+	}
+
+	policy := cfg.KeepaliveEnforcement
+	if policy == (keepalive.EnforcementPolicy{}) {
+		policy = DefaultKeepaliveEnforcement
+	}
+
+	return []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(recv),
+		grpc.MaxSendMsgSize(send),
+		grpc.KeepaliveParams(params),
+		grpc.KeepaliveEnforcementPolicy(policy),
+	}
+
+}
+
 type GRPCServer struct {
+	mu                 sync.Mutex
 	server             *grpc.Server
+	stopped            bool
+	listener           net.Listener
+	credentials        credentials.TransportCredentials
+	statsHandlers      []stats.Handler
+	options            []grpc.ServerOption
 	reflection         bool
 	services           []GRPCService
 	config             GRPCServerConfig
@@ -150,13 +338,72 @@ func (s *GRPCServer) StartCancellable(
 
 func (s *GRPCServer) executeStart(onError func(error)) {
 
-	conn, err := net.Listen(netx.TCP, ":"+s.config.PortString())
-	if err != nil {
-		onError(err)
+	conn := s.listener
+	if conn == nil {
+		listener, err := net.Listen(netx.TCP, s.config.Address())
+		if err != nil {
+			onError(err)
+			return
+		}
+		conn = listener
+	}
+
+	go func() {
+		err := s.Serve(conn)
+		if err != nil {
+			onError(err)
+			return
+		}
+	}()
+
+}
+
+// Serve - starts gRPC server on the provided listener and blocks until it is stopped.
+// It returns nil when the server is stopped by Stop or StopContext.
+func (s *GRPCServer) Serve(conn net.Listener) error {
+
+	server := s.prepare()
+	if server == nil {
+		_ = conn.Close()
+		return nil
+	}
+
+	return server.Serve(conn)
+
+}
+
+// ServeHTTP - serves a gRPC request received by an HTTP/2 server,
+// so that gRPC shares the port with HTTP, see grpc.Server.ServeHTTP for the limitations.
+func (s *GRPCServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	server := s.prepare()
+	if server == nil {
+		http.Error(w, "grpc server is stopped", http.StatusServiceUnavailable)
 		return
 	}
 
-	s.server = grpc.NewServer(
+	server.ServeHTTP(w, r)
+
+}
+
+// prepare - creates the server and registers the services once,
+// returns nil when the server has been stopped.
+func (s *GRPCServer) prepare() *grpc.Server {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return nil
+	}
+
+	if s.server != nil {
+		return s.server
+	}
+
+	options := s.config.ServerOptions()
+	options = append(
+		options,
 		grpc.StreamInterceptor(
 			grpcmw.ChainStreamServer(
 				s.streamInterceptors...,
@@ -169,23 +416,65 @@ func (s *GRPCServer) executeStart(onError func(error)) {
 		),
 	)
 
+	if s.credentials != nil {
+		options = append(options, grpc.Creds(s.credentials))
+	}
+
+	for _, handler := range s.statsHandlers {
+		options = append(options, grpc.StatsHandler(handler))
+	}
+
+	options = append(options, s.options...)
+
+	s.server = grpc.NewServer(options...)
+
 	s.RegisterServices(s.services...)
 
 	if s.reflection {
 		reflection.Register(s.server)
 	}
 
-	go func() {
-		err = s.server.Serve(conn)
-		if err != nil {
-			onError(err)
-			return
-		}
-	}()
+	return s.server
 
 }
 
 // Stop - gracefully stops gRPC server.
 func (s *GRPCServer) Stop() {
-	s.server.GracefulStop()
+	if server := s.grpcServer(); server != nil {
+		server.GracefulStop()
+	}
+}
+
+// StopContext - gracefully stops gRPC server,
+// pending RPCs are cancelled and connections are closed when the context is done.
+func (s *GRPCServer) StopContext(ctx context.Context) error {
+
+	server := s.grpcServer()
+	if server == nil {
+		return nil
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		server.GracefulStop()
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		server.Stop()
+		<-stopped
+		return ctx.Err()
+	}
+
+}
+
+// grpcServer - returns the running server and prevents starting it once it is stopped.
+func (s *GRPCServer) grpcServer() *grpc.Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	return s.server
 }
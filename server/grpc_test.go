@@ -0,0 +1,37 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/keepalive"
+)
+
+func TestWithGrpcServerConfig_Merge(t *testing.T) {
+
+	// given
+	params := keepalive.ServerParameters{Time: time.Hour}
+
+	// when
+	server := NewGrpcServer(
+		WithMaxRecvMsgSize(8<<20),
+		WithKeepalive(params),
+		WithGrpcServerConfig(*NewGRPCServerAddressConfig("127.0.0.1", 9000)),
+		WithGrpcServerConfig(GRPCServerConfig{MaxSendMsgSize: 1 << 20}),
+	)
+
+	// then
+	if server.config.Address() != "127.0.0.1:9000" {
+		t.Errorf("Address() = '%s', want '%s'", server.config.Address(), "127.0.0.1:9000")
+	}
+
+	if server.config.MaxRecvMsgSize != 8<<20 || server.config.MaxSendMsgSize != 1<<20 {
+		t.Errorf("message sizes = %d, %d, want the earlier options kept",
+			server.config.MaxRecvMsgSize, server.config.MaxSendMsgSize)
+	}
+
+	if server.config.Keepalive != params {
+		t.Errorf("Keepalive = %+v, want %+v", server.config.Keepalive, params)
+	}
+
+}
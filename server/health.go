@@ -0,0 +1,209 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const DefaultHealthWatchInterval = time.Second
+
+// HealthStatusFunc - reports the serving status of the service, the empty name stands for the whole server.
+// The second result is false when the service is unknown.
+type HealthStatusFunc func(ctx context.Context, service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, bool)
+
+// HealthServiceOp - is callback function that applies an option to HealthService.
+type HealthServiceOp func(*HealthService)
+
+// WithHealthWatchInterval - sets how often HealthService re-evaluates the status for Watch streams,
+// the status of a service is evaluated once per interval however many streams watch it.
+func WithHealthWatchInterval(interval time.Duration) HealthServiceOp {
+	return func(service *HealthService) {
+		service.interval = interval
+	}
+}
+
+// HealthService - implements the standard grpc.health.v1 service on top of HealthStatusFunc,
+// so that the status is evaluated on request instead of being pushed to the server.
+type HealthService struct {
+	grpc_health_v1.UnimplementedHealthServer
+	status   HealthStatusFunc
+	interval time.Duration
+
+	mu       sync.Mutex
+	watchers map[*healthWatcher]struct{}
+	stop     context.CancelFunc
+}
+
+// healthWatcher - receives the latest status of the service of a Watch stream.
+type healthWatcher struct {
+	service string
+	updates chan grpc_health_v1.HealthCheckResponse_ServingStatus
+}
+
+// NewHealthService - creates new HealthService with options via provided HealthServiceOp.
+func NewHealthService(status HealthStatusFunc, ops ...HealthServiceOp) *HealthService {
+	service := &HealthService{
+		status:   status,
+		interval: DefaultHealthWatchInterval,
+		watchers: make(map[*healthWatcher]struct{}),
+	}
+
+	for _, op := range ops {
+		op(service)
+	}
+
+	return service
+}
+
+// RegisterService - registers the grpc.health.v1 service, see GRPCService.
+func (s *HealthService) RegisterService(registrar grpc.ServiceRegistrar) {
+	grpc_health_v1.RegisterHealthServer(registrar, s)
+}
+
+// Check - reports the current status, unknown services are reported with codes.NotFound.
+func (s *HealthService) Check(
+	ctx context.Context,
+	req *grpc_health_v1.HealthCheckRequest,
+) (*grpc_health_v1.HealthCheckResponse, error) {
+
+	current, ok := s.status(ctx, req.GetService())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown service: '%s'", req.GetService())
+	}
+
+	return &grpc_health_v1.HealthCheckResponse{Status: current}, nil
+
+}
+
+// Watch - sends the current status and then every change of it until the client goes away,
+// unknown services are reported with SERVICE_UNKNOWN as the protocol requires.
+func (s *HealthService) Watch(
+	req *grpc_health_v1.HealthCheckRequest,
+	stream grpc.ServerStreamingServer[grpc_health_v1.HealthCheckResponse],
+) error {
+
+	last := s.evaluate(stream.Context(), req.GetService())
+	if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: last}); err != nil {
+		return err
+	}
+
+	watcher := s.subscribe(req.GetService())
+	defer s.unsubscribe(watcher)
+
+	for {
+
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case current := <-watcher.updates:
+			if current == last {
+				continue
+			}
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+
+	}
+
+}
+
+func (s *HealthService) evaluate(ctx context.Context, service string) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	current, ok := s.status(ctx, service)
+	if !ok {
+		return grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+	return current
+}
+
+// subscribe - adds the watcher, the first one starts the polling.
+func (s *HealthService) subscribe(service string) *healthWatcher {
+
+	watcher := &healthWatcher{
+		service: service,
+		updates: make(chan grpc_health_v1.HealthCheckResponse_ServingStatus, 1),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.watchers[watcher] = struct{}{}
+
+	if s.stop == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.stop = cancel
+		go s.poll(ctx)
+	}
+
+	return watcher
+
+}
+
+// unsubscribe - removes the watcher, the last one stops the polling.
+func (s *HealthService) unsubscribe(watcher *healthWatcher) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.watchers, watcher)
+
+	if len(s.watchers) == 0 && s.stop != nil {
+		s.stop()
+		s.stop = nil
+	}
+
+}
+
+// poll - evaluates the status of every watched service once per interval and fans it out to the watchers.
+func (s *HealthService) poll(ctx context.Context) {
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		services := make(map[string]grpc_health_v1.HealthCheckResponse_ServingStatus)
+		for watcher := range s.watchers {
+			services[watcher.service] = grpc_health_v1.HealthCheckResponse_UNKNOWN
+		}
+		s.mu.Unlock()
+
+		// The checks run without the lock, so that watchers come and go meanwhile.
+		for service := range services {
+			services[service] = s.evaluate(ctx, service)
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.mu.Lock()
+		for watcher := range s.watchers {
+			if current, ok := services[watcher.service]; ok {
+				// Only the latest status matters, a status not received yet is replaced.
+				select {
+				case <-watcher.updates:
+				default:
+				}
+				watcher.updates <- current
+			}
+		}
+		s.mu.Unlock()
+
+	}
+
+}
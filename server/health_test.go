@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type watchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent atomic.Int32
+}
+
+func (s *watchStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *watchStream) Send(*grpc_health_v1.HealthCheckResponse) error {
+	s.sent.Add(1)
+	return nil
+}
+
+func TestHealthService_WatchSharesEvaluation(t *testing.T) {
+
+	// given
+	const watchers = 5
+	const interval = 10 * time.Millisecond
+
+	var evaluations atomic.Int32
+	service := NewHealthService(
+		func(context.Context, string) (grpc_health_v1.HealthCheckResponse_ServingStatus, bool) {
+			evaluations.Add(1)
+			return grpc_health_v1.HealthCheckResponse_SERVING, true
+		},
+		WithHealthWatchInterval(interval),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	streams := make([]*watchStream, 0, watchers)
+	group := sync.WaitGroup{}
+
+	// when
+	for range watchers {
+		stream := &watchStream{ctx: ctx}
+		streams = append(streams, stream)
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			_ = service.Watch(&grpc_health_v1.HealthCheckRequest{}, stream)
+		}()
+	}
+
+	time.Sleep(20 * interval)
+	cancel()
+	group.Wait()
+
+	// then
+	// Every stream evaluates the status once when it starts, the ticks are shared.
+	if actual := evaluations.Load(); actual > watchers+25 {
+		t.Errorf("evaluations = %d, want at most %d for %d watchers", actual, watchers+25, watchers)
+	}
+
+	for _, stream := range streams {
+		if stream.sent.Load() != 1 {
+			t.Errorf("sent = %d, want the unchanged status once", stream.sent.Load())
+		}
+	}
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	if len(service.watchers) != 0 || service.stop != nil {
+		t.Error("watchers are left after the streams ended")
+	}
+
+}
@@ -0,0 +1,326 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/evgenivanovi/gpl/pg"
+	"github.com/evgenivanovi/gpl/std"
+	slogx "github.com/evgenivanovi/gpl/stdx/log/slog"
+	"github.com/evgenivanovi/gpl/stdx/xsync"
+	grpcmw "github.com/grpc-ecosystem/go-grpc-middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDHeader - the metadata key carrying the request id, it is also sent back in the response header.
+const RequestIDHeader = "x-request-id"
+
+type contextKey string
+
+const requestIDKey contextKey = "ctx.server.request_id"
+
+// persistenceCodes - the gRPC codes of pg.PersistenceError codes, the rest are internal errors.
+var persistenceCodes = map[string]codes.Code{
+	pg.ErrorEmptyCode:  codes.NotFound,
+	pg.ErrorUniqueCode: codes.AlreadyExists,
+	pg.ErrorLockCode:   codes.Aborted,
+}
+
+var persistenceMessages = map[string]string{
+	pg.ErrorEmptyCode:  pg.ErrorEmptyMessage,
+	pg.ErrorUniqueCode: pg.ErrorUniqueMessage,
+	pg.ErrorLockCode:   pg.ErrorLockMessage,
+}
+
+// WithStandardInterceptors - add the unary and stream interceptors of StandardUnaryInterceptors
+// and StandardStreamInterceptors to GrpcServer, before the interceptors added by later options.
+func WithStandardInterceptors(deadline time.Duration) GRPCServerOp {
+	return func(server *GRPCServer) {
+		server.unaryInterceptors = append(server.unaryInterceptors, StandardUnaryInterceptors(deadline)...)
+		server.streamInterceptors = append(server.streamInterceptors, StandardStreamInterceptors(deadline)...)
+	}
+}
+
+// StandardUnaryInterceptors - returns the request id, logging, recovery, deadline and error interceptors in this order,
+// so that the log records the request id and the status of panics and translated errors.
+// A non-positive deadline leaves the deadline of the client as it is.
+func StandardUnaryInterceptors(deadline time.Duration) []grpc.UnaryServerInterceptor {
+	return []grpc.UnaryServerInterceptor{
+		RequestIDUnaryInterceptor(),
+		LoggingUnaryInterceptor(),
+		RecoveryUnaryInterceptor(),
+		DeadlineUnaryInterceptor(deadline),
+		ErrorUnaryInterceptor(),
+	}
+}
+
+// StandardStreamInterceptors - the stream counterpart of StandardUnaryInterceptors.
+func StandardStreamInterceptors(deadline time.Duration) []grpc.StreamServerInterceptor {
+	return []grpc.StreamServerInterceptor{
+		RequestIDStreamInterceptor(),
+		LoggingStreamInterceptor(),
+		RecoveryStreamInterceptor(),
+		DeadlineStreamInterceptor(deadline),
+		ErrorStreamInterceptor(),
+	}
+}
+
+// RecoveryUnaryInterceptor - turns a panic of the handler into the Internal status,
+// the panic is logged with the stack as xsync does and the details are not sent to the client.
+func RecoveryUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+
+		var resp any
+
+		err := xsync.RunErrorFunc(func() error {
+			var err error
+			resp, err = handler(ctx, req)
+			return err
+		})
+
+		return resp, recovered(err)
+
+	}
+}
+
+// RecoveryStreamInterceptor - the stream counterpart of RecoveryUnaryInterceptor.
+func RecoveryStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return recovered(xsync.RunErrorFunc(func() error {
+			return handler(srv, stream)
+		}))
+	}
+}
+
+func recovered(err error) error {
+	if errors.Is(err, xsync.ErrRecovered) {
+		return status.Error(codes.Internal, "internal error")
+	}
+	return err
+}
+
+// LoggingUnaryInterceptor - logs every call with the method, the status code and the duration
+// through the logger of the context, see slogx.FromCtx. The handler gets the logger with the method.
+// Calls failing on the server side are logged as errors, the rest as information.
+func LoggingUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx = slogx.WithKV(ctx, "grpc.method", info.FullMethod)
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(ctx, start, err)
+		return resp, err
+	}
+}
+
+// LoggingStreamInterceptor - the stream counterpart of LoggingUnaryInterceptor.
+func LoggingStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		wrapped := grpcmw.WrapServerStream(stream)
+		wrapped.WrappedContext = slogx.WithKV(wrapped.WrappedContext, "grpc.method", info.FullMethod)
+		start := time.Now()
+		err := handler(srv, wrapped)
+		logCall(wrapped.WrappedContext, start, err)
+		return err
+	}
+}
+
+func logCall(ctx context.Context, start time.Time, err error) {
+
+	code := status.Code(err)
+
+	level := slog.LevelInfo
+	switch code {
+	case codes.Unknown, codes.Internal, codes.DataLoss, codes.Unavailable, codes.Unimplemented:
+		level = slog.LevelError
+	}
+
+	attrs := []slog.Attr{
+		slog.String("grpc.code", code.String()),
+		slog.Duration("grpc.duration", time.Since(start)),
+	}
+
+	if err != nil {
+		attrs = append(attrs, slogx.ErrAttr(err))
+	}
+
+	slogx.FromCtx(ctx).LogAttrs(ctx, level, "gRPC call finished", attrs...)
+
+}
+
+// RequestIDUnaryInterceptor - takes the request id from RequestIDHeader of the incoming metadata
+// or generates one, puts it in the context and the logger of the context and sends it back in the header.
+func RequestIDUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx = withRequestID(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, RequestIDFromCtx(ctx)))
+		return handler(ctx, req)
+	}
+}
+
+// RequestIDStreamInterceptor - the stream counterpart of RequestIDUnaryInterceptor.
+func RequestIDStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		wrapped := grpcmw.WrapServerStream(stream)
+		wrapped.WrappedContext = withRequestID(wrapped.WrappedContext)
+		_ = stream.SetHeader(metadata.Pairs(RequestIDHeader, RequestIDFromCtx(wrapped.WrappedContext)))
+		return handler(srv, wrapped)
+	}
+}
+
+// RequestIDFromCtx - returns the request id of the call, empty outside RequestIDUnaryInterceptor
+// and RequestIDStreamInterceptor.
+func RequestIDFromCtx(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey).(string); ok {
+		return id
+	}
+	return std.Empty
+}
+
+func withRequestID(ctx context.Context) context.Context {
+
+	id := std.Empty
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDHeader); len(values) > 0 {
+			id = values[0]
+		}
+	}
+
+	if id == std.Empty {
+		id = newRequestID()
+	}
+
+	ctx = context.WithValue(ctx, requestIDKey, id)
+	return slogx.WithKV(ctx, "request.id", id)
+
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// DeadlineUnaryInterceptor - limits the call to the deadline when the client sets none or a later one,
+// and rejects the call with DeadlineExceeded when its deadline has already passed.
+// A non-positive deadline leaves the deadline of the client as it is.
+func DeadlineUnaryInterceptor(deadline time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+
+		ctx, cancel, err := withDeadline(ctx, deadline)
+		if err != nil {
+			return nil, err
+		}
+		defer cancel()
+
+		return handler(ctx, req)
+
+	}
+}
+
+// DeadlineStreamInterceptor - the stream counterpart of DeadlineUnaryInterceptor.
+func DeadlineStreamInterceptor(deadline time.Duration) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		ctx, cancel, err := withDeadline(stream.Context(), deadline)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+
+		wrapped := grpcmw.WrapServerStream(stream)
+		wrapped.WrappedContext = ctx
+		return handler(srv, wrapped)
+
+	}
+}
+
+func withDeadline(ctx context.Context, deadline time.Duration) (context.Context, context.CancelFunc, error) {
+
+	if ctx.Err() != nil {
+		return ctx, nil, status.FromContextError(ctx.Err()).Err()
+	}
+
+	if deadline <= 0 {
+		return ctx, func() {}, nil
+	}
+
+	if current, ok := ctx.Deadline(); ok && time.Until(current) <= deadline {
+		return ctx, func() {}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deadline)
+	return ctx, cancel, nil
+
+}
+
+// ErrorUnaryInterceptor - translates the errors of the handler to gRPC statuses, see ToStatus,
+// the details of persistence errors are logged through the logger of the context.
+func ErrorUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		return resp, toStatus(ctx, err)
+	}
+}
+
+// ErrorStreamInterceptor - the stream counterpart of ErrorUnaryInterceptor.
+func ErrorStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return toStatus(stream.Context(), handler(srv, stream))
+	}
+}
+
+// ToStatus - translates the error to a gRPC status: statuses are kept,
+// pg.PersistenceError codes empty, unique and lock become NotFound, AlreadyExists and Aborted,
+// other persistence errors become Internal, context errors become DeadlineExceeded and Canceled,
+// and the rest are kept for gRPC to report as Unknown.
+// Persistence errors are sent with the standard message of their code, as their own message
+// may carry the database error, e.g. the constraint name, the details are logged instead.
+func ToStatus(err error) error {
+	return toStatus(context.Background(), err)
+}
+
+func toStatus(ctx context.Context, err error) error {
+
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var persistence *pg.PersistenceError
+	if errors.As(err, &persistence) {
+
+		code, found := persistenceCodes[persistence.Code]
+		message := persistenceMessages[persistence.Code]
+		if !found {
+			code, message = codes.Internal, pg.ErrorInternalMessage
+		}
+
+		slogx.FromCtx(ctx).LogAttrs(
+			ctx,
+			std.If(code == codes.Internal, slog.LevelError, slog.LevelDebug),
+			"Persistence error translated to gRPC status",
+			slog.String("grpc.code", code.String()),
+			slogx.ErrAttr(err),
+		)
+
+		return status.Error(code, message)
+
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return status.FromContextError(err).Err()
+	}
+
+	return err
+
+}
@@ -0,0 +1,362 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evgenivanovi/gpl/pg"
+	slogx "github.com/evgenivanovi/gpl/stdx/log/slog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var unaryInfo = &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+
+var streamInfo = &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream", IsServerStream: true}
+
+type testStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (s *testStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+// captureLog
+// Returns the context carrying the logger writing every level to the returned buffer.
+func captureLog(ctx context.Context) (context.Context, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return slogx.WithCtx(ctx, logger), buf
+}
+
+func assertLogged(t *testing.T, log string, parts ...string) {
+	t.Helper()
+	for _, part := range parts {
+		if !strings.Contains(log, part) {
+			t.Errorf("log = '%s', want '%s' logged", log, part)
+		}
+	}
+}
+
+func TestToStatus(t *testing.T) {
+
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{name: "empty", err: pg.NewErrorWithCode(pg.ErrorEmptyCode), code: codes.NotFound},
+		{name: "unique", err: pg.NewErrorWithCode(pg.ErrorUniqueCode), code: codes.AlreadyExists},
+		{name: "lock", err: pg.NewErrorWithCode(pg.ErrorLockCode), code: codes.Aborted},
+		{name: "integrity", err: pg.NewErrorWithCode(pg.ErrorIntegrityCode), code: codes.Internal},
+		{name: "wrapped", err: fmt.Errorf("find: %w", pg.NewErrorWithCode(pg.ErrorEmptyCode)), code: codes.NotFound},
+		{name: "status", err: status.Error(codes.InvalidArgument, "invalid"), code: codes.InvalidArgument},
+		{name: "deadline", err: context.DeadlineExceeded, code: codes.DeadlineExceeded},
+		{name: "other", err: errors.New("other"), code: codes.Unknown},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			// when
+			err := ToStatus(test.err)
+
+			// then
+			if code := status.Code(err); code != test.code {
+				t.Errorf("ToStatus(%v) = %s, want %s", test.err, code, test.code)
+			}
+
+		})
+	}
+
+	if ToStatus(nil) != nil {
+		t.Error("ToStatus(nil) is not nil")
+	}
+
+}
+
+func TestToStatus_PersistenceMessage(t *testing.T) {
+
+	// given
+	raw := `ERROR: duplicate key value violates unique constraint "orders_number_key" (SQLSTATE 23505)`
+	err := pg.NewErrorWithCodeMessage(pg.ErrorUniqueCode, raw)
+
+	// when
+	translated := status.Convert(ToStatus(err))
+
+	// then
+	if translated.Code() != codes.AlreadyExists {
+		t.Errorf("ToStatus() code = %s, want %s", translated.Code(), codes.AlreadyExists)
+	}
+
+	if translated.Message() != pg.ErrorUniqueMessage {
+		t.Errorf("ToStatus() message = '%s', want '%s'", translated.Message(), pg.ErrorUniqueMessage)
+	}
+
+}
+
+func TestRecoveryUnaryInterceptor(t *testing.T) {
+
+	// given
+	interceptor := RecoveryUnaryInterceptor()
+	handler := func(context.Context, any) (any, error) {
+		panic("boom")
+	}
+
+	// when
+	_, err := interceptor(context.Background(), nil, unaryInfo, handler)
+
+	// then
+	if status.Code(err) != codes.Internal {
+		t.Errorf("interceptor() = %v, want %s", err, codes.Internal)
+	}
+
+}
+
+func TestRequestIDUnaryInterceptor(t *testing.T) {
+
+	// given
+	interceptor := RequestIDUnaryInterceptor()
+	incoming := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDHeader, "42"))
+
+	var given, generated string
+
+	// when
+	_, _ = interceptor(incoming, nil, unaryInfo, func(ctx context.Context, _ any) (any, error) {
+		given = RequestIDFromCtx(ctx)
+		return nil, nil
+	})
+
+	_, _ = interceptor(context.Background(), nil, unaryInfo, func(ctx context.Context, _ any) (any, error) {
+		generated = RequestIDFromCtx(ctx)
+		return nil, nil
+	})
+
+	// then
+	if given != "42" {
+		t.Errorf("RequestIDFromCtx() = '%s', want '42'", given)
+	}
+
+	if generated == "" {
+		t.Error("RequestIDFromCtx() is empty without the metadata")
+	}
+
+}
+
+func TestDeadlineUnaryInterceptor(t *testing.T) {
+
+	// given
+	interceptor := DeadlineUnaryInterceptor(time.Second)
+
+	short, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	expired, expire := context.WithCancel(context.Background())
+	expire()
+
+	var unlimited, limited time.Duration
+	deadline := func(target *time.Duration) grpc.UnaryHandler {
+		return func(ctx context.Context, _ any) (any, error) {
+			if current, ok := ctx.Deadline(); ok {
+				*target = time.Until(current)
+			}
+			return nil, nil
+		}
+	}
+
+	// when
+	_, _ = interceptor(context.Background(), nil, unaryInfo, deadline(&unlimited))
+	_, _ = interceptor(short, nil, unaryInfo, deadline(&limited))
+	_, err := interceptor(expired, nil, unaryInfo, deadline(new(time.Duration)))
+
+	// then
+	if unlimited <= 0 || unlimited > time.Second {
+		t.Errorf("deadline without a client deadline = %s, want at most %s", unlimited, time.Second)
+	}
+
+	if limited <= 0 || limited > 100*time.Millisecond {
+		t.Errorf("deadline with a shorter client deadline = %s, want the client one", limited)
+	}
+
+	if status.Code(err) != codes.Canceled {
+		t.Errorf("interceptor() of a cancelled call = %v, want %s", err, codes.Canceled)
+	}
+
+}
+
+func TestLoggingUnaryInterceptor(t *testing.T) {
+
+	// given
+	ctx, log := captureLog(context.Background())
+	interceptor := LoggingUnaryInterceptor()
+
+	// when
+	_, err := interceptor(ctx, nil, unaryInfo, func(ctx context.Context, _ any) (any, error) {
+		slogx.FromCtx(ctx).Info("handling")
+		return nil, status.Error(codes.Internal, "database is down")
+	})
+
+	// then
+	if status.Convert(err).Message() != "database is down" {
+		t.Errorf("interceptor() = %v, want the error of the handler", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("log = '%s', want the handler and the call records", log)
+	}
+
+	assertLogged(t, lines[0], "msg=handling", "grpc.method=/test.Service/Method")
+	assertLogged(t, lines[1],
+		"level=ERROR", "grpc.method=/test.Service/Method", "grpc.code=Internal", `error="rpc error: code = Internal desc = database is down"`,
+	)
+
+}
+
+func TestLoggingStreamInterceptor(t *testing.T) {
+
+	// given
+	ctx, log := captureLog(context.Background())
+	interceptor := LoggingStreamInterceptor()
+
+	// when
+	err := interceptor(nil, &testStream{ctx: ctx}, streamInfo, func(_ any, stream grpc.ServerStream) error {
+		slogx.FromCtx(stream.Context()).Info("streaming")
+		return nil
+	})
+
+	// then
+	if err != nil {
+		t.Errorf("interceptor() unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("log = '%s', want the handler and the call records", log)
+	}
+
+	assertLogged(t, lines[0], "msg=streaming", "grpc.method=/test.Service/Stream")
+	assertLogged(t, lines[1], "level=INFO", "grpc.method=/test.Service/Stream", "grpc.code=OK")
+
+	if strings.Contains(lines[1], "error=") {
+		t.Errorf("log = '%s', want no error of a successful call", lines[1])
+	}
+
+}
+
+func TestRecoveryStreamInterceptor(t *testing.T) {
+
+	// given
+	interceptor := RecoveryStreamInterceptor()
+
+	// when
+	err := interceptor(nil, &testStream{ctx: context.Background()}, streamInfo, func(any, grpc.ServerStream) error {
+		panic("secret state")
+	})
+
+	// then
+	translated := status.Convert(err)
+
+	if translated.Code() != codes.Internal {
+		t.Errorf("interceptor() code = %s, want %s", translated.Code(), codes.Internal)
+	}
+
+	if strings.Contains(translated.Message(), "secret state") {
+		t.Errorf("interceptor() message = '%s', reveals the panic", translated.Message())
+	}
+
+}
+
+func TestRequestIDStreamInterceptor(t *testing.T) {
+
+	// given
+	interceptor := RequestIDStreamInterceptor()
+	incoming := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDHeader, "42"))
+	ctx, log := captureLog(incoming)
+	stream := &testStream{ctx: ctx}
+
+	var given string
+
+	// when
+	_ = interceptor(nil, stream, streamInfo, func(_ any, stream grpc.ServerStream) error {
+		given = RequestIDFromCtx(stream.Context())
+		slogx.FromCtx(stream.Context()).Info("streaming")
+		return nil
+	})
+
+	// then
+	if given != "42" {
+		t.Errorf("RequestIDFromCtx() = '%s', want '42'", given)
+	}
+
+	if values := stream.header.Get(RequestIDHeader); len(values) != 1 || values[0] != "42" {
+		t.Errorf("header %s = %v, want '42' sent back", RequestIDHeader, values)
+	}
+
+	assertLogged(t, log.String(), "request.id=42")
+
+}
+
+func TestErrorUnaryInterceptor_LogsDetails(t *testing.T) {
+
+	// given
+	raw := "ERROR: insert or update violates foreign key constraint orders_customer_fkey (SQLSTATE 23503)"
+	ctx, log := captureLog(context.Background())
+	interceptor := ErrorUnaryInterceptor()
+
+	// when
+	_, err := interceptor(ctx, nil, unaryInfo, func(context.Context, any) (any, error) {
+		return nil, pg.NewErrorWithCodeMessage(pg.ErrorIntegrityCode, raw)
+	})
+
+	// then
+	translated := status.Convert(err)
+
+	if translated.Code() != codes.Internal || translated.Message() != pg.ErrorInternalMessage {
+		t.Errorf("interceptor() = %s '%s', want %s '%s'",
+			translated.Code(), translated.Message(), codes.Internal, pg.ErrorInternalMessage)
+	}
+
+	assertLogged(t, log.String(), "level=ERROR", "grpc.code=Internal", "orders_customer_fkey")
+
+}
+
+func TestErrorStreamInterceptor(t *testing.T) {
+
+	// given
+	raw := "ERROR: duplicate key value violates unique constraint orders_number_key (SQLSTATE 23505)"
+	ctx, log := captureLog(context.Background())
+	interceptor := ErrorStreamInterceptor()
+
+	// when
+	err := interceptor(nil, &testStream{ctx: ctx}, streamInfo, func(any, grpc.ServerStream) error {
+		return fmt.Errorf("save order: %w", pg.NewErrorWithCodeMessage(pg.ErrorUniqueCode, raw))
+	})
+
+	// then
+	translated := status.Convert(err)
+
+	if translated.Code() != codes.AlreadyExists || translated.Message() != pg.ErrorUniqueMessage {
+		t.Errorf("interceptor() = %s '%s', want %s '%s'",
+			translated.Code(), translated.Message(), codes.AlreadyExists, pg.ErrorUniqueMessage)
+	}
+
+	assertLogged(t, log.String(), "level=DEBUG", "grpc.code=AlreadyExists", "orders_number_key")
+
+}
@@ -31,6 +31,13 @@ func Level() zapcore.Level {
 	return level.Level()
 }
 
+// AtomicLevel
+// Returns the level of the default logger,
+// it serves GET and PUT requests to read and change the level over HTTP.
+func AtomicLevel() zap.AtomicLevel {
+	return level
+}
+
 func SetLog(new *zap.Logger) {
 	logger = new
 	sugar = logger.Sugar()
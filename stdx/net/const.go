@@ -1,3 +1,4 @@
 package net
 
 const TCP = "tcp"
+const Unix = "unix"